@@ -1,90 +1,318 @@
 package jsonassert
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-func (a *Asserter) checkArray(path string, act, exp []interface{}) {
-	a.tt.Helper()
-	if len(exp) > 0 && exp[0] == "<<UNORDERED>>" {
-		a.checkArrayUnordered(path, act, exp[1:])
+func (c *comparer) checkArray(path string, act, exp []interface{}) {
+	if !c.cfg.directives || len(exp) == 0 {
+		c.checkArrayOrdered(path, act, exp)
+		return
+	}
+	switch exp[0] {
+	case "<<UNORDERED>>":
+		c.checkArrayUnordered(path, act, exp[1:])
+	case "<<CONTAINS>>":
+		c.checkArrayContains(path, act, exp[1:])
+	case "<<CONTAINS_IN_ORDER>>":
+		c.checkArrayContainsInOrder(path, act, exp[1:])
+	default:
+		if s, ok := exp[0].(string); ok {
+			if name, args, ok := parseDirective(s); ok {
+				if _, ok := lengthBounds[name]; ok {
+					c.checkArrayLength(path, act, s, exp[1:], name, args)
+					return
+				}
+			}
+		}
+		c.checkArrayOrdered(path, act, exp)
+	}
+}
+
+// lengthBound describes the lengths of arrays accepted by a length directive,
+// such as "<<MIN_LEN:1>>".
+type lengthBound struct {
+	description string
+	accept      func(length, bound int) bool
+}
+
+var lengthBounds = map[string]lengthBound{
+	"LEN":       {"exactly", func(length, bound int) bool { return length == bound }},
+	"MIN_LEN":   {"at least", func(length, bound int) bool { return length >= bound }},
+	"MAX_LEN":   {"at most", func(length, bound int) bool { return length <= bound }},
+	"NOT_EMPTY": {"at least", func(length, bound int) bool { return length >= bound }},
+}
+
+// lengthDirective creates a directive that verifies the length of the actual
+// array, when given in place of an array, e.g. "<<LEN:3>>".
+func lengthDirective(name string) valueDirective {
+	return func(c *comparer, path string, act interface{}, args string) {
+		arr, ok := act.([]interface{})
+		if !ok {
+			c.report(TypeMismatch, path, act, nil,
+				fmt.Sprintf("expected an array at '%s' but found %s", path, typeOf(act)))
+			return
+		}
+		c.checkLength(path, arr, name, args)
+	}
+}
+
+// checkArrayLength verifies the length of the actual array, when a length
+// directive is given as the first element of the expected array. The
+// directive may be followed by an element template that every element of the
+// actual array must match, e.g. ["<<MIN_LEN:1>>", {"id": "<<UUID>>"}].
+func (c *comparer) checkArrayLength(path string, act []interface{}, directive string, templates []interface{}, name, args string) {
+	if len(templates) > 1 {
+		c.report(DirectiveFailure, path, act, directive,
+			fmt.Sprintf("expected at most one element template after '%s' at '%s' but found %d", directive, path, len(templates)))
+		return
+	}
+	c.checkLength(path, act, name, args)
+	if len(templates) == 1 {
+		for i, actEl := range act {
+			c.pathassertf(fmt.Sprintf("%s[%d]", path, i), serialize(actEl), serialize(templates[0]))
+		}
+	}
+}
+
+func (c *comparer) checkLength(path string, act []interface{}, name, args string) {
+	bound, lengthBound := 1, lengthBounds[name]
+	if name == "NOT_EMPTY" {
+		if args != "" {
+			c.reportInvalidDirective(path, act, name, args, errors.New("expected no arguments"))
+			return
+		}
 	} else {
-		a.checkArrayOrdered(path, act, exp)
+		n, err := strconv.Atoi(args)
+		if err != nil || n < 0 {
+			c.reportInvalidDirective(path, act, name, args, fmt.Errorf("'%s' is not a valid length", args))
+			return
+		}
+		bound = n
+	}
+	if !lengthBound.accept(len(act), bound) {
+		c.report(LengthMismatch, path, len(act), bound,
+			fmt.Sprintf("expected array at '%s' to have %s %d element(s) but it had %d", path, lengthBound.description, bound, len(act)))
+	}
+}
+
+// checkArrayContains verifies that every expected element can be found in the
+// actual array, regardless of order and ignoring any additional elements.
+func (c *comparer) checkArrayContains(path string, act, exp []interface{}) {
+	expMatches, actMatches := c.matchElements(act, exp)
+	c.replayMatches(path, act, exp, actMatches)
+	for i, expEl := range exp {
+		if expMatches[i] == -1 {
+			c.reportMissingElement(path, i, expEl, "was missing from actual payload")
+		}
+	}
+}
+
+// checkArrayContainsInOrder verifies that the expected elements can be found
+// in the actual array in the same order, ignoring any additional elements.
+func (c *comparer) checkArrayContainsInOrder(path string, act, exp []interface{}) {
+	// Matching each expected element to the first matching actual element
+	// after the previous match is guaranteed to find a subsequence if there
+	// is one.
+	next := 0
+	for i, expEl := range exp {
+		found := false
+		for j := next; j < len(act); j++ {
+			if c.matches(act[j], expEl) {
+				c.replayMatch(fmt.Sprintf("%s[%d]", path, j), act[j], expEl)
+				next, found = j+1, true
+				break
+			}
+		}
+		if !found {
+			c.reportMissingElement(path, i, expEl, "was missing from actual payload, or out of order")
+		}
 	}
 }
 
-func (a *Asserter) checkArrayUnordered(path string, act, exp []interface{}) {
-	a.tt.Helper()
+func (c *comparer) reportMissingElement(path string, i int, expEl interface{}, problem string) {
+	elPath := fmt.Sprintf("%s[%d]", path, i)
+	serializedEl := serialize(expEl)
+	if len(serializedEl) < c.cfg.lineLength {
+		c.report(MissingElement, elPath, nil, expEl,
+			fmt.Sprintf("expected JSON at '%s': %s %s", elPath, serializedEl, problem))
+	} else {
+		c.report(MissingElement, elPath, nil, expEl,
+			fmt.Sprintf("expected JSON at '%s':\n%s\n%s", elPath, serializedEl, problem))
+	}
+}
+
+func (c *comparer) checkArrayUnordered(path string, act, exp []interface{}) {
 	if len(act) != len(exp) {
-		a.tt.Errorf("length of arrays at '%s' were different. Expected array to be of length %d, but contained %d element(s)", path, len(exp), len(act))
 		serializedAct, serializedExp := serialize(act), serialize(exp)
-		if len(serializedAct+serializedExp) < 50 {
-			a.tt.Errorf("actual JSON at '%s' was: %+v, but expected JSON was: %+v, potentially in a different order", path, serializedAct, serializedExp)
+		lengthMsg := fmt.Sprintf("length of arrays at '%s' were different. Expected array to be of length %d, but contained %d element(s)", path, len(exp), len(act))
+		if len(serializedAct+serializedExp) < c.cfg.lineLength {
+			c.report(LengthMismatch, path, act, exp, lengthMsg,
+				fmt.Sprintf("actual JSON at '%s' was: %+v, but expected JSON was: %+v, potentially in a different order", path, serializedAct, serializedExp))
 		} else {
-			a.tt.Errorf("actual JSON at '%s' was:\n%+v\nbut expected JSON was:\n%+v,\npotentially in a different order", path, serializedAct, serializedExp)
+			c.report(LengthMismatch, path, act, exp, lengthMsg,
+				fmt.Sprintf("actual JSON at '%s' was:\n%+v\nbut expected JSON was:\n%+v,\npotentially in a different order", path, serializedAct, serializedExp))
 		}
 		return
 	}
 
+	expMatches, actMatches := c.matchElements(act, exp)
+	c.replayMatches(path, act, exp, actMatches)
+	for _, pair := range c.closestPairs(act, exp, actMatches, expMatches) {
+		actPath, expPath := fmt.Sprintf("%s[%d]", path, pair.act), fmt.Sprintf("%s[%d]", path, pair.exp)
+		c.report(ExtraElement, actPath, act[pair.act], exp[pair.exp],
+			fmt.Sprintf("actual JSON at '%s' did not match any expected element, differences to the closest expected element at '%s' follow", actPath, expPath))
+		c.pathassertf(actPath, serialize(act[pair.act]), serialize(exp[pair.exp]))
+		actMatches[pair.act], expMatches[pair.exp] = pair.exp, pair.act
+	}
 	for i, actEl := range act {
-		found := false
-		for _, expEl := range exp {
-			if a.deepEqual(actEl, expEl) {
-				found = true
-			}
-		}
-		if !found {
+		if actMatches[i] == -1 {
+			elPath := fmt.Sprintf("%s[%d]", path, i)
 			serializedEl := serialize(actEl)
-			if len(serializedEl) < 50 {
-				a.tt.Errorf("actual JSON at '%s[%d]' contained an unexpected element: %s", path, i, serializedEl)
+			if len(serializedEl) < c.cfg.lineLength {
+				c.report(ExtraElement, elPath, actEl, nil,
+					fmt.Sprintf("actual JSON at '%s' contained an unexpected element: %s", elPath, serializedEl))
 			} else {
-				a.tt.Errorf("actual JSON at '%s[%d]' contained an unexpected element:\n%s", path, i, serializedEl)
+				c.report(ExtraElement, elPath, actEl, nil,
+					fmt.Sprintf("actual JSON at '%s' contained an unexpected element:\n%s", elPath, serializedEl))
 			}
 		}
 	}
 
 	for i, expEl := range exp {
-		found := false
-		for _, actEl := range act {
-			found = found || a.deepEqual(expEl, actEl)
+		if expMatches[i] == -1 {
+			c.reportMissingElement(path, i, expEl, "was missing from actual payload")
 		}
-		if !found {
-			serializedEl := serialize(expEl)
-			if len(serializedEl) < 50 {
-				a.tt.Errorf("expected JSON at '%s[%d]': %s was missing from actual payload", path, i, serializedEl)
-			} else {
-				a.tt.Errorf("expected JSON at '%s[%d]':\n%s\nwas missing from actual payload", path, i, serializedEl)
+	}
+}
+
+type elementPair struct {
+	act, exp int
+	diffs    int
+}
+
+// closestPairs pairs up unmatched actual objects (or arrays) with the
+// unmatched expected objects (or arrays) that they have the fewest differences
+// to, so that these differences can be reported instead of the entire
+// elements.
+func (c *comparer) closestPairs(act, exp []interface{}, actMatches, expMatches []int) []elementPair {
+	candidates := []elementPair{}
+	for i, actEl := range act {
+		for j, expEl := range exp {
+			if actMatches[i] != -1 || expMatches[j] != -1 || !sameCompositeType(actEl, expEl) {
+				continue
 			}
+			candidates = append(candidates, elementPair{act: i, exp: j, diffs: len(c.subcompare(actEl, expEl).diffs)})
 		}
 	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].diffs < candidates[j].diffs })
+
+	pairs := []elementPair{}
+	actPaired, expPaired := make([]bool, len(act)), make([]bool, len(exp))
+	for _, candidate := range candidates {
+		if !actPaired[candidate.act] && !expPaired[candidate.exp] {
+			actPaired[candidate.act], expPaired[candidate.exp] = true, true
+			pairs = append(pairs, candidate)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].act < pairs[j].act })
+	return pairs
 }
 
-func (a *Asserter) deepEqual(act, exp interface{}) bool {
-	// There's a non-zero chance that JSON serialization will *not* be
-	// deterministic in the future like it is in v1.16.
-	// However, until this is the case, I can't seem to find a test case that
-	// makes this evaluation return a false positive.
-	// The benefit is a lot of simplicity and considerable performance benefits
-	// for large nested structures.
-	return serialize(act) == serialize(exp)
+func sameCompositeType(act, exp interface{}) bool {
+	switch act.(type) {
+	case map[string]interface{}:
+		_, ok := exp.(map[string]interface{})
+		return ok
+	case []interface{}:
+		_, ok := exp.([]interface{})
+		return ok
+	}
+	return false
+}
+
+// matches reports whether the actual value satisfies the expected value,
+// directives and all, without reporting any differences.
+func (c *comparer) matches(act, exp interface{}) bool {
+	sub := c.subcompare(act, exp)
+	return sub.err == nil && len(sub.diffs) == 0
+}
+
+// subcompare compares the actual value against the expected value in a
+// separate comparer, so that any differences are not reported.
+func (c *comparer) subcompare(act, exp interface{}) *comparer {
+	sub := newComparer(c.cfg)
+	sub.extraKeysAllowed = c.extraKeysAllowed
+	sub.captured, sub.references = c.captured, c.references
+	sub.pathassertf("$", serialize(act), serialize(exp))
+	return sub
+}
+
+// matchElements pairs up as many actual and expected elements that match each
+// other as possible, using each element at most once. It returns, for each
+// expected element, the index of its actual element, and vice versa. Elements
+// that could not be paired up have an index of -1.
+func (c *comparer) matchElements(act, exp []interface{}) (expMatches, actMatches []int) {
+	candidates := make([][]int, len(exp))
+	for i, expEl := range exp {
+		for j, actEl := range act {
+			if c.matches(actEl, expEl) {
+				candidates[i] = append(candidates[i], j)
+			}
+		}
+	}
+
+	expMatches, actMatches = make([]int, len(exp)), make([]int, len(act))
+	for i := range expMatches {
+		expMatches[i] = -1
+	}
+	for j := range actMatches {
+		actMatches[j] = -1
+	}
+
+	// Simply pairing up each expected element with the first free actual
+	// element it matches is not enough, as directives like "<<PRESENCE>>"
+	// match more than one element. Instead, find a maximum bipartite matching
+	// with augmenting paths (Kuhn's algorithm).
+	var augment func(i int, visited []bool) bool
+	augment = func(i int, visited []bool) bool {
+		for _, j := range candidates[i] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if actMatches[j] == -1 || augment(actMatches[j], visited) {
+				expMatches[i], actMatches[j] = j, i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range exp {
+		augment(i, make([]bool, len(act)))
+	}
+	return expMatches, actMatches
 }
 
-func (a *Asserter) checkArrayOrdered(path string, act, exp []interface{}) {
-	a.tt.Helper()
+func (c *comparer) checkArrayOrdered(path string, act, exp []interface{}) {
 	if len(act) != len(exp) {
-		a.tt.Errorf("length of arrays at '%s' were different. Expected array to be of length %d, but contained %d element(s)", path, len(exp), len(act))
 		serializedAct, serializedExp := serialize(act), serialize(exp)
-		if len(serializedAct+serializedExp) < 50 {
-			a.tt.Errorf("actual JSON at '%s' was: %+v, but expected JSON was: %+v", path, serializedAct, serializedExp)
+		lengthMsg := fmt.Sprintf("length of arrays at '%s' were different. Expected array to be of length %d, but contained %d element(s)", path, len(exp), len(act))
+		if len(serializedAct+serializedExp) < c.cfg.lineLength {
+			c.report(LengthMismatch, path, act, exp, lengthMsg,
+				fmt.Sprintf("actual JSON at '%s' was: %+v, but expected JSON was: %+v", path, serializedAct, serializedExp))
 		} else {
-			a.tt.Errorf("actual JSON at '%s' was:\n%+v\nbut expected JSON was:\n%+v", path, serializedAct, serializedExp)
+			c.report(LengthMismatch, path, act, exp, lengthMsg,
+				fmt.Sprintf("actual JSON at '%s' was:\n%+v\nbut expected JSON was:\n%+v", path, serializedAct, serializedExp))
 		}
 		return
 	}
 	for i := range act {
-		a.pathassertf(path+fmt.Sprintf("[%d]", i), serialize(act[i]), serialize(exp[i]))
+		c.pathassertf(path+fmt.Sprintf("[%d]", i), serialize(act[i]), serialize(exp[i]))
 	}
 }
 
@@ -94,6 +322,6 @@ func extractArray(s string) ([]interface{}, error) {
 		return nil, fmt.Errorf("cannot parse empty string as array")
 	}
 	var arr []interface{}
-	err := json.Unmarshal([]byte(s), &arr)
+	err := unmarshal(s, &arr)
 	return arr, err
 }
@@ -12,9 +12,9 @@ func extractBoolean(b string) (bool, error) {
 	return false, fmt.Errorf("could not parse '%s' as a boolean", b)
 }
 
-func (a *Asserter) checkBoolean(path string, act, exp bool) {
-	a.tt.Helper()
+func (c *comparer) checkBoolean(path string, act, exp bool) {
 	if act != exp {
-		a.tt.Errorf("expected boolean at '%s' to be %v but was %v", path, exp, act)
+		c.report(ValueMismatch, path, act, exp,
+			fmt.Sprintf("expected boolean at '%s' to be %v but was %v", path, exp, act))
 	}
 }
@@ -0,0 +1,166 @@
+package jsonassert
+
+import (
+	"errors"
+	"fmt"
+)
+
+// occurrence is a "<<CAPTURE:name>>" or "<<SAME:name>>" directive found in the
+// expected JSON, along with the actual value at its path.
+type occurrence struct {
+	name, path string
+	act        interface{}
+}
+
+// checkCapture records the actual value so that it can be compared against by
+// "<<SAME:name>>", and read back with Asserter.Captured.
+func checkCapture(c *comparer, path string, act interface{}, args string) {
+	if args == "" {
+		c.reportInvalidDirective(path, act, "CAPTURE", args, errors.New("expected a name"))
+		return
+	}
+	c.captures = append(c.captures, occurrence{name: args, path: path, act: act})
+}
+
+// reference is the value that "<<SAME:name>>" is compared against, along with
+// a description of where it was captured.
+type reference struct {
+	value       interface{}
+	description string
+}
+
+// checkSame verifies that the actual value equals the value captured by
+// "<<CAPTURE:name>>". The captures are collected before the actual comparison
+// starts, see comparer.compare, so that this also works within arrays whose
+// elements are matched regardless of order. While the captures are being
+// collected, references is nil and any value is accepted.
+func checkSame(c *comparer, path string, act interface{}, args string) {
+	if args == "" {
+		c.reportInvalidDirective(path, act, "SAME", args, errors.New("expected a name"))
+		return
+	}
+	if c.references == nil {
+		return
+	}
+	ref, ok := c.references[args]
+	if !ok {
+		c.report(DirectiveFailure, path, act, "<<SAME:"+args+">>",
+			fmt.Sprintf("expected the value at '%s' to be the same as the value captured as '%s', but nothing was captured as '%s'", path, args, args))
+		return
+	}
+	c.checkSameValue(occurrence{name: args, path: path, act: act}, ref.value, ref.description)
+}
+
+// collectReferences returns the values that "<<SAME:name>>" is compared
+// against: the first capture of each name, in path order (object keys sorted),
+// or else the value captured by an earlier assertion.
+func (c *comparer) collectReferences() map[string]reference {
+	references := map[string]reference{}
+	for name, value := range c.captured {
+		references[name] = reference{value, fmt.Sprintf("the value captured as '%s' by an earlier assertion", name)}
+	}
+	first := map[string]bool{}
+	for _, capture := range c.captures {
+		if !first[capture.name] {
+			first[capture.name] = true
+			references[capture.name] = reference{capture.act, fmt.Sprintf("the value captured as '%s' at '%s'", capture.name, capture.path)}
+		}
+	}
+	return references
+}
+
+// checkCaptures verifies that all the values captured under the same name are
+// equal. The first capture of a name, in path order (object keys sorted),
+// decides what the other values are compared against.
+func (c *comparer) checkCaptures() {
+	// The occurrences are recorded in the order in which they were visited,
+	// which is path order, as object keys are visited in sorted order rather
+	// than in the order in which they appear in the payload.
+	first := map[string]occurrence{}
+	for _, capture := range c.captures {
+		if want, ok := first[capture.name]; ok {
+			c.checkSameValue(capture, want.act, fmt.Sprintf("the value captured as '%s' at '%s'", want.name, want.path))
+			continue
+		}
+		first[capture.name] = capture
+	}
+}
+
+func (c *comparer) checkSameValue(o occurrence, want interface{}, description string) {
+	// The values are both actual values, so any directives in them are
+	// nothing but strings.
+	cfg := *c.cfg
+	cfg.directives = false
+	sub := newComparer(&cfg)
+	sub.extraKeysAllowed = false
+	sub.pathassertf("$", serialize(o.act), serialize(want))
+	if len(sub.diffs) > 0 {
+		c.report(DirectiveFailure, o.path, o.act, want,
+			fmt.Sprintf("expected the value at '%s' to be the same as %s, which was %s, but was %s", o.path, description, serialize(want), serialize(o.act)))
+	}
+}
+
+// newlyCaptured returns the values captured in this payload by name.
+func (c *comparer) newlyCaptured() map[string]interface{} {
+	captured := map[string]interface{}{}
+	for i := len(c.captures) - 1; i >= 0; i-- {
+		captured[c.captures[i].name] = c.captures[i].act
+	}
+	return captured
+}
+
+// containsDirective reports whether the expected value contains the named
+// directive, with any arguments.
+func containsDirective(exp interface{}, name string) bool {
+	switch v := exp.(type) {
+	case string:
+		found, _, ok := parseDirective(v)
+		return ok && found == name
+	case map[string]interface{}:
+		for _, val := range v {
+			if containsDirective(val, name) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, el := range v {
+			if containsDirective(el, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replayMatch compares an actual element against the expected element it was
+// matched with once more, so that any values captured within them are
+// recorded. Elements are matched in separate comparers, which don't record
+// anything.
+func (c *comparer) replayMatch(path string, act, exp interface{}) {
+	if containsDirective(exp, "CAPTURE") {
+		c.pathassertf(path, serialize(act), serialize(exp))
+	}
+}
+
+// replayMatches replays the matched pairs of elements in the order of the
+// actual elements.
+func (c *comparer) replayMatches(path string, act, exp []interface{}, actMatches []int) {
+	for j, i := range actMatches {
+		if i != -1 {
+			c.replayMatch(fmt.Sprintf("%s[%d]", path, j), act[j], exp[i])
+		}
+	}
+}
+
+// Captured returns the value that was captured as the given name by
+// "<<CAPTURE:name>>" in the most recent assertion made with this Asserter that
+// captured it, whether or not that assertion passed. The value is decoded in
+// the same way as Difference.Actual, e.g. numbers are json.Numbers. The bool
+// is false if nothing was captured as the given name.
+//
+//	ja.Assertf(body, `{"id": "<<CAPTURE:orderID>>"}`)
+//	id, _ := ja.Captured("orderID")
+func (a *Asserter) Captured(name string) (interface{}, bool) {
+	v, ok := a.captured[name]
+	return v, ok
+}
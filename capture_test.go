@@ -0,0 +1,44 @@
+package jsonassert_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/kubient/jsonassert"
+)
+
+func TestCapture(t *testing.T) {
+	t.Run("captured strings are not directives", func(t *testing.T) {
+		if jsonassert.New(&testPrinter{}).Assertf(`["<<STRING>>", "x"]`, `["<<CAPTURE:s>>", "<<SAME:s>>"]`) {
+			t.Errorf("expected \"x\" to differ from the captured \"<<STRING>>\"")
+		}
+	})
+
+	t.Run("across assertions", func(t *testing.T) {
+		tp := &testPrinter{}
+		ja := jsonassert.New(tp)
+		if _, ok := ja.Captured("id"); ok {
+			t.Errorf("expected nothing to be captured initially")
+		}
+		ja.Assertf(`{"id": "abc", "n": 1.50}`, `{"id": "<<CAPTURE:id>>", "n": "<<CAPTURE:n>>"}`)
+		if id, ok := ja.Captured("id"); !ok || id != "abc" {
+			t.Errorf("expected 'abc' to be captured as 'id' but got %v", id)
+		}
+		if n, ok := ja.Captured("n"); !ok || n != json.Number("1.50") {
+			t.Errorf("expected 1.50 to be captured as 'n' but got %v", n)
+		}
+
+		ja.Assertf(`{"order_id": "abc"}`, `{"order_id": "<<SAME:id>>"}`)
+		ja.Assertf(`{"order_id": "def"}`, `{"order_id": "<<SAME:id>>"}`)
+		want := []string{`expected the value at '$.order_id' to be the same as the value captured as 'id' by an earlier assertion, which was "abc", but was "def"`}
+		if !reflect.DeepEqual(tp.messages, want) {
+			t.Errorf("expected messages %q but got %q", want, tp.messages)
+		}
+
+		ja.Assertf(`{"id": "ghi"}`, `{"id": "<<CAPTURE:id>>"}`)
+		if id, _ := ja.Captured("id"); id != "ghi" {
+			t.Errorf("expected 'ghi' to be captured as 'id' but got %v", id)
+		}
+	})
+}
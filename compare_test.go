@@ -0,0 +1,129 @@
+package jsonassert_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/kubient/jsonassert"
+)
+
+func TestCompare(t *testing.T) {
+	t.Run("equal payloads", func(t *testing.T) {
+		diffs, err := jsonassert.Compare(`{"hello": "world", "uuid": "abc"}`, `{"hello": "world", "uuid": "<<PRESENCE>>"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(diffs) != 0 {
+			t.Errorf("expected no differences but got %v", diffs)
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		for name, tc := range map[string]struct{ act, exp, msg string }{
+			"actual":   {`{`, `{}`, `'actual' JSON is not valid JSON: unable to identify JSON type of "{"`},
+			"expected": {`{}`, `{`, `'expected' JSON is not valid JSON: unable to identify JSON type of "{"`},
+		} {
+			t.Run(name, func(t *testing.T) {
+				diffs, err := jsonassert.Compare(tc.act, tc.exp)
+				if err == nil || err.Error() != tc.msg {
+					t.Errorf("expected error '%s' but got '%v'", tc.msg, err)
+				}
+				if diffs != nil {
+					t.Errorf("expected no differences alongside an error but got %v", diffs)
+				}
+			})
+		}
+	})
+
+	t.Run("stable order", func(t *testing.T) {
+		act := `{"j": 0, "i": 0, "h": 0, "g": 0, "f": 0, "e": 0, "d": 0, "c": 0, "b": 0, "a": 0, "x": 1, "z": 1, "y": 1}`
+		exp := `{"a": 1, "b": 1, "c": 1, "d": 1, "e": 1, "f": 1, "g": 1, "h": 1, "i": 1, "j": 1, "w": 1, "v": 1}`
+		first, err := jsonassert.Compare(act, exp)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var paths []string
+		for _, diff := range first {
+			paths = append(paths, diff.Path)
+		}
+		wantPaths := []string{"$", "$", "$", "$.a", "$.b", "$.c", "$.d", "$.e", "$.f", "$.g", "$.h", "$.i", "$.j"}
+		if !reflect.DeepEqual(paths, wantPaths) {
+			t.Errorf("expected differences at %v but got %v", wantPaths, paths)
+		}
+		if got, want := first[1].Actual, []string{"x", "y", "z"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected extra keys %v but got %v", want, got)
+		}
+		if got, want := first[2].Expected, []string{"v", "w"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected missing keys %v but got %v", want, got)
+		}
+		for i := 0; i < 20; i++ {
+			if diffs, _ := jsonassert.Compare(act, exp); !reflect.DeepEqual(diffs, first) {
+				t.Fatalf("expected the same differences in every run, but got %v and %v", first, diffs)
+			}
+		}
+	})
+
+	t.Run("kinds of differences", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			act, exp string
+			want     []jsonassert.Difference
+		}{
+			"type mismatch": {
+				`{"a": "1"}`, `{"a": 1}`,
+				[]jsonassert.Difference{{Path: "$.a", Kind: jsonassert.TypeMismatch, Actual: "1", Expected: json.Number("1")}},
+			},
+			"value mismatch": {
+				`[true]`, `[false]`,
+				[]jsonassert.Difference{{Path: "$[0]", Kind: jsonassert.ValueMismatch, Actual: true, Expected: false}},
+			},
+			"missing key": {
+				`{"a": 1, "b": 2}`, `{"a": 1, "c": 2}`,
+				[]jsonassert.Difference{
+					{Path: "$", Kind: jsonassert.ExtraKey, Actual: []string{"b"}},
+					{Path: "$", Kind: jsonassert.MissingKey, Expected: []string{"c"}},
+				},
+			},
+			"length mismatch": {
+				`[1]`, `[1, 2]`,
+				[]jsonassert.Difference{{Path: "$", Kind: jsonassert.LengthMismatch, Actual: []interface{}{json.Number("1")}, Expected: []interface{}{json.Number("1"), json.Number("2")}}},
+			},
+			"unordered elements": {
+				`["a"]`, `["<<UNORDERED>>", "b"]`,
+				[]jsonassert.Difference{
+					{Path: "$[0]", Kind: jsonassert.ExtraElement, Actual: "a"},
+					{Path: "$[0]", Kind: jsonassert.MissingElement, Expected: "b"},
+				},
+			},
+			"directive failure": {
+				`{"a": null}`, `{"a": "<<PRESENCE>>"}`,
+				[]jsonassert.Difference{{Path: "$.a", Kind: jsonassert.DirectiveFailure, Expected: "<<PRESENCE>>"}},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				diffs, err := jsonassert.Compare(tc.act, tc.exp)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(diffs) != len(tc.want) {
+					t.Fatalf("expected %d difference(s) but got %d: %v", len(tc.want), len(diffs), diffs)
+				}
+				for i, want := range tc.want {
+					got := diffs[i]
+					if got.Path != want.Path || got.Kind != want.Kind ||
+						!reflect.DeepEqual(got.Actual, want.Actual) || !reflect.DeepEqual(got.Expected, want.Expected) {
+						t.Errorf("expected difference %s but got %s", describe(want), describe(got))
+					}
+					if got.String() == "" {
+						t.Errorf("expected difference at '%s' to have a description", got.Path)
+					}
+				}
+			})
+		}
+	})
+}
+
+func describe(d jsonassert.Difference) string {
+	return fmt.Sprintf("{Path: %s, Kind: %s, Actual: %#v, Expected: %#v}", d.Path, d.Kind, d.Actual, d.Expected)
+}
@@ -4,54 +4,109 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 )
 
-func (a *Asserter) pathassertf(path, act, exp string) {
-	a.tt.Helper()
+// comparer holds the state of a single comparison between an actual and an
+// expected JSON payload.
+type comparer struct {
+	cfg   *config
+	diffs []Difference
+	err   error
+
+	// extraKeysAllowed is true when objects nested within the value being
+	// compared may contain keys that are not in the expected objects.
+	extraKeysAllowed bool
+
+	// captures are the "<<CAPTURE:name>>" directives found so far, see
+	// checkCaptures.
+	captures []occurrence
+	// captured holds the values captured by earlier assertions.
+	captured map[string]interface{}
+	// references holds the values that "<<SAME:name>>" is compared against,
+	// or nil while they are still being collected, see checkSame.
+	references map[string]reference
+}
+
+func newComparer(cfg *config) *comparer {
+	return &comparer{cfg: cfg, extraKeysAllowed: cfg.extraKeysAllowed}
+}
+
+// report records a difference at the given path. Each of the given messages
+// is given to the Printer separately by Assertf.
+func (c *comparer) report(kind DifferenceKind, path string, act, exp interface{}, msgs ...string) {
+	c.diffs = append(c.diffs, Difference{Path: path, Kind: kind, Actual: act, Expected: exp, msgs: msgs})
+}
+
+func (c *comparer) pathassertf(path, act, exp string) {
 	if act == exp {
 		return
 	}
 	actType, err := findType(act)
 	if err != nil {
-		a.tt.Errorf("'actual' JSON is not valid JSON: " + err.Error())
+		c.err = fmt.Errorf("'actual' JSON is not valid JSON: %w", err)
 		return
 	}
 	expType, err := findType(exp)
 	if err != nil {
-		a.tt.Errorf("'expected' JSON is not valid JSON: " + err.Error())
+		c.err = fmt.Errorf("'expected' JSON is not valid JSON: %w", err)
 		return
 	}
 
-	// If we're only caring about the presence of the key, then don't bother checking any further
-	if expPresence, _ := extractString(exp); expPresence == "<<PRESENCE>>" {
-		if actType == jsonNull {
-			a.tt.Errorf(`expected the presence of any value at '%s', but was absent`, path)
+	if c.cfg.directives && expType == jsonObject {
+		expObject, _ := extractObject(exp)
+		if directive, wrapped, ok := unwrap(expObject); ok {
+			switch directive {
+			case "<<OPTIONAL>>":
+				// The key is present, or we wouldn't be here.
+				c.pathassertf(path, act, serialize(wrapped))
+			case "<<NULLABLE>>":
+				if actType != jsonNull {
+					c.pathassertf(path, act, serialize(wrapped))
+				}
+			}
+			return
 		}
-		return
 	}
 
-	// check for reg ex
-	if expType == jsonString {
+	if c.cfg.directives && expType == jsonString {
 
 		expString, _ := extractString(exp)
 
-		if yes, err := isRegEx(expString); err == nil && yes {
-
-			var actString string
-			if actType == jsonString {
-				actString, _ = extractString(act)
-			} else {
-				actString = act
+		// check for reg ex
+		if pattern, ok := c.regexPattern(expString); ok {
+			if pattern == "" {
+				// An empty pattern would silently match anything.
+				c.report(DirectiveFailure, path, decode(act), expString,
+					fmt.Sprintf("invalid directive '%s' at '%s': expected a pattern", expString, path))
+				return
+			}
+			if actString, ok := c.regexSubject(path, act, actType, expString); ok {
+				c.checkRegex(path, actString, expString, pattern)
 			}
+			return
+		}
 
-			a.checkString(path, actString, expString)
+		// Directives such as "<<PRESENCE>>" decide for themselves what
+		// actual values they accept, so don't bother checking any further
+		if name, args, ok := parseDirective(expString); ok {
+			if directive, ok := valueDirectives[name]; ok {
+				directive(c, path, decode(act), args)
+				return
+			}
+			if fn, ok := c.cfg.customDirective(name); ok {
+				customDirective(name, fn)(c, path, decode(act), args)
+				return
+			}
+			c.reportUnknownDirective(path, decode(act), expString, name)
 			return
 		}
 	}
 
 	if actType != expType {
-		a.tt.Errorf("actual JSON (%s) and expected JSON (%s) were of different types at '%s'", actType, expType, path)
+		c.report(TypeMismatch, path, decode(act), decode(exp),
+			fmt.Sprintf("actual JSON (%s) and expected JSON (%s) were of different types at '%s'", actType, expType, path))
 		return
 	}
 
@@ -59,23 +114,23 @@ func (a *Asserter) pathassertf(path, act, exp string) {
 	case jsonBoolean:
 		actBool, _ := extractBoolean(act)
 		expBool, _ := extractBoolean(exp)
-		a.checkBoolean(path, actBool, expBool)
+		c.checkBoolean(path, actBool, expBool)
 	case jsonNumber:
 		actNumber, _ := extractNumber(act)
 		expNumber, _ := extractNumber(exp)
-		a.checkNumber(path, actNumber, expNumber)
+		c.checkNumber(path, actNumber, expNumber)
 	case jsonString:
 		actString, _ := extractString(act)
 		expString, _ := extractString(exp)
-		a.checkString(path, actString, expString)
+		c.checkStringEquality(path, actString, expString)
 	case jsonObject:
 		actObject, _ := extractObject(act)
 		expObject, _ := extractObject(exp)
-		a.checkObject(path, actObject, expObject)
+		c.checkObject(path, actObject, expObject)
 	case jsonArray:
 		actArray, _ := extractArray(act)
 		expArray, _ := extractArray(exp)
-		a.checkArray(path, actArray, expArray)
+		c.checkArray(path, actArray, expArray)
 	}
 }
 
@@ -89,6 +144,30 @@ func serialize(a interface{}) string {
 	return string(bytes)
 }
 
+// decode is the inverse of serialize, and is only ever given valid JSON. Besides
+// populating Differences, e.g. those reported by regexSubject, it decodes the
+// actual values given to every directive, including custom DirectiveFuncs, so
+// that these always see numbers as json.Numbers.
+func decode(s string) interface{} {
+	var v interface{}
+	_ = unmarshal(s, &v)
+	return v
+}
+
+// unmarshal works like json.Unmarshal, but decodes numbers into json.Numbers
+// so that no precision is lost on large integers or long decimals.
+func unmarshal(s string, v interface{}) error {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("invalid data after top-level value in '%s'", s)
+	}
+	return nil
+}
+
 type jsonType string
 
 const (
@@ -0,0 +1,105 @@
+package jsonassert
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DirectiveFunc verifies that the actual value at the given path satisfies a
+// custom directive, and returns an error describing why it doesn't otherwise.
+// The actual value is decoded in the same way as Difference.Actual, e.g.
+// numbers are given as json.Numbers. The arguments are everything after the
+// colon in the directive, e.g. "EUR" for "<<CURRENCY:EUR>>", or the empty
+// string if the directive has no arguments.
+type DirectiveFunc func(path string, actual interface{}, args string) error
+
+var (
+	globalDirectivesMu sync.RWMutex
+	globalDirectives   = map[string]DirectiveFunc{}
+)
+
+// reservedDirectives are the built-in directive names that aren't value
+// directives, and so can't be looked up in valueDirectives.
+var reservedDirectives = map[string]bool{
+	"UNORDERED":          true,
+	"CONTAINS":           true,
+	"CONTAINS_IN_ORDER":  true,
+	"EXTRA_KEYS_ALLOWED": true,
+	"RECURSIVE":          true,
+	"REGEX":              true,
+}
+
+/*
+RegisterDirective makes the directive with the given name available to all
+Asserters, as well as to Compare. E.g. after
+
+	jsonassert.RegisterDirective("CURRENCY", func(path string, actual interface{}, args string) error {
+		if s, ok := actual.(string); !ok || len(s) != 3 {
+			return fmt.Errorf("expected a currency code at '%s' but found %v", path, actual)
+		}
+		return nil
+	})
+
+"<<CURRENCY>>" may be used in place of any value in an expected JSON. Typically
+this is done from an init function or TestMain. Directives registered on an
+Asserter take precedence over the ones registered here.
+
+RegisterDirective panics if the name isn't made up of upper case letters,
+digits and underscores, or if it is the name of a built-in directive such as
+"PRESENCE".
+*/
+func RegisterDirective(name string, fn DirectiveFunc) {
+	mustBeValidDirectiveName(name)
+	globalDirectivesMu.Lock()
+	defer globalDirectivesMu.Unlock()
+	globalDirectives[name] = fn
+}
+
+// RegisterDirective makes the directive with the given name available to
+// this Asserter only. See the package level RegisterDirective for details.
+func (a *Asserter) RegisterDirective(name string, fn DirectiveFunc) {
+	mustBeValidDirectiveName(name)
+	// Copy the map so that Asserters never share their directives.
+	directives := make(map[string]DirectiveFunc, len(a.cfg.customDirectives)+1)
+	for k, v := range a.cfg.customDirectives {
+		directives[k] = v
+	}
+	directives[name] = fn
+	a.cfg.customDirectives = directives
+}
+
+func mustBeValidDirectiveName(name string) {
+	// Names with a colon would parse, but their directives would be looked
+	// up by the part before the colon only.
+	if parsed, _, ok := parseDirective("<<" + name + ">>"); !ok || parsed != name {
+		panic(fmt.Sprintf("jsonassert: invalid directive name '%s': must be made up of upper case letters, digits and underscores", name))
+	}
+	if _, ok := valueDirectives[name]; ok || reservedDirectives[name] {
+		panic(fmt.Sprintf("jsonassert: cannot register directive '%s': it is a built-in directive", name))
+	}
+}
+
+// customDirective looks up a custom directive, first on the Asserter and then
+// globally.
+func (cfg *config) customDirective(name string) (DirectiveFunc, bool) {
+	if fn, ok := cfg.customDirectives[name]; ok {
+		return fn, true
+	}
+	globalDirectivesMu.RLock()
+	defer globalDirectivesMu.RUnlock()
+	fn, ok := globalDirectives[name]
+	return fn, ok
+}
+
+// customDirective adapts a DirectiveFunc to a valueDirective.
+func customDirective(name string, fn DirectiveFunc) valueDirective {
+	return func(c *comparer, path string, act interface{}, args string) {
+		if err := fn(path, act, args); err != nil {
+			directive := "<<" + name + ">>"
+			if args != "" {
+				directive = fmt.Sprintf("<<%s:%s>>", name, args)
+			}
+			c.report(DirectiveFailure, path, act, directive, err.Error())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package jsonassert_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kubient/jsonassert"
+)
+
+func TestRegisterDirective(t *testing.T) {
+	jsonassert.RegisterDirective("TEST_CURRENCY", func(path string, actual interface{}, args string) error {
+		s, ok := actual.(string)
+		if !ok || len(s) != 3 || strings.ToUpper(s) != s {
+			return fmt.Errorf("expected a currency code at '%s' but found %v", path, actual)
+		}
+		if args != "" && s != args {
+			return fmt.Errorf("expected currency '%s' at '%s' but found '%s'", args, path, s)
+		}
+		return nil
+	})
+	orderID := func(path string, actual interface{}, _ string) error {
+		if s, ok := actual.(string); !ok || !strings.HasPrefix(s, "ORD-") {
+			return fmt.Errorf("expected an order ID at '%s' but found %v", path, actual)
+		}
+		return nil
+	}
+	even := func(path string, actual interface{}, _ string) error {
+		if n, ok := actual.(json.Number); !ok || !strings.ContainsAny(string(n[len(n)-1]), "02468") {
+			return fmt.Errorf("expected an even number at '%s' but found %v", path, actual)
+		}
+		return nil
+	}
+
+	for name, tc := range map[string]*testCase{
+		"global directive":                  {`{"c": "EUR"}`, `{"c": "<<TEST_CURRENCY>>"}`, nil},
+		"global directive with arguments":   {`{"c": "EUR"}`, `{"c": "<<TEST_CURRENCY:EUR>>"}`, nil},
+		"asserter directive":                {`{"id": "ORD-1"}`, `{"id": "<<ORDER_ID>>"}`, nil},
+		"directive given the decoded value": {`[2, 4]`, `["<<EVEN>>", "<<EVEN>>"]`, nil},
+		"failing global directive": {
+			`{"c": "eur"}`,
+			`{"c": "<<TEST_CURRENCY>>"}`,
+			[]string{`expected a currency code at '$.c' but found eur`},
+		},
+		"failing directive with arguments": {
+			`{"c": "USD"}`,
+			`{"c": "<<TEST_CURRENCY:EUR>>"}`,
+			[]string{`expected currency 'EUR' at '$.c' but found 'USD'`},
+		},
+		"failing asserter directive": {
+			`{"id": 1}`,
+			`{"id": "<<ORDER_ID>>"}`,
+			[]string{`expected an order ID at '$.id' but found 1`},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.checkAssertion(t, func(ja *jsonassert.Asserter) bool {
+				ja.RegisterDirective("ORDER_ID", orderID)
+				ja.RegisterDirective("EVEN", even)
+				return ja.Assertf(tc.act, tc.exp)
+			})
+		})
+	}
+
+	t.Run("asserter directives are not shared", func(t *testing.T) {
+		tp := &testPrinter{}
+		jsonassert.New(tp).Assertf(`{"id": "ORD-1"}`, `{"id": "<<ORDER_ID>>"}`)
+		if len(tp.messages) == 0 {
+			t.Errorf("expected '<<ORDER_ID>>' to be unknown to a new Asserter")
+		}
+	})
+
+	t.Run("asserter directives take precedence", func(t *testing.T) {
+		tp := &testPrinter{}
+		ja := jsonassert.New(tp)
+		ja.RegisterDirective("TEST_CURRENCY", func(string, interface{}, string) error { return nil })
+		if !ja.Assertf(`{"c": 1}`, `{"c": "<<TEST_CURRENCY>>"}`) {
+			t.Errorf("expected the Asserter's directive to be used, but got %q", tp.messages)
+		}
+	})
+
+	t.Run("global directives in Compare", func(t *testing.T) {
+		diffs, err := jsonassert.Compare(`{"c": "EURO"}`, `{"c": "<<TEST_CURRENCY>>"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(diffs) != 1 || diffs[0].Kind != jsonassert.DirectiveFailure || diffs[0].Expected != "<<TEST_CURRENCY>>" {
+			t.Errorf("expected a single directive failure but got %v", diffs)
+		}
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		for _, name := range []string{"", "lower", "WITH SPACE", "A:B", "A:", "PRESENCE", "UNORDERED", "EXTRA_KEYS_ALLOWED"} {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("expected registering '%s' to panic", name)
+					}
+				}()
+				jsonassert.New(&testPrinter{}).RegisterDirective(name, func(string, interface{}, string) error { return nil })
+			})
+		}
+	})
+}
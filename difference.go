@@ -0,0 +1,85 @@
+package jsonassert
+
+import "strings"
+
+// DifferenceKind categorizes a Difference between an actual and an expected
+// JSON payload.
+type DifferenceKind int
+
+const (
+	// TypeMismatch means that the actual and expected values were of
+	// different JSON types, e.g. a string and a number.
+	TypeMismatch DifferenceKind = iota + 1
+	// MissingKey means that the expected object contained key(s) that were
+	// not present in the actual object.
+	MissingKey
+	// ExtraKey means that the actual object contained key(s) that were not
+	// present in the expected object.
+	ExtraKey
+	// ValueMismatch means that the actual and expected values were of the
+	// same type, but were not equal.
+	ValueMismatch
+	// LengthMismatch means that the actual and expected arrays or objects
+	// were of different sizes.
+	LengthMismatch
+	// MissingElement means that an element of an expected array could not be
+	// found in the actual array.
+	MissingElement
+	// ExtraElement means that an element of an actual array could not be
+	// found in the expected array.
+	ExtraElement
+	// DirectiveFailure means that the actual value did not satisfy a
+	// directive, such as "<<PRESENCE>>" or a regular expression, in the
+	// expected JSON.
+	DirectiveFailure
+)
+
+func (k DifferenceKind) String() string {
+	switch k {
+	case TypeMismatch:
+		return "type mismatch"
+	case MissingKey:
+		return "missing key"
+	case ExtraKey:
+		return "extra key"
+	case ValueMismatch:
+		return "value mismatch"
+	case LengthMismatch:
+		return "length mismatch"
+	case MissingElement:
+		return "missing element"
+	case ExtraElement:
+		return "extra element"
+	case DirectiveFailure:
+		return "directive failure"
+	}
+	return "unknown"
+}
+
+// Difference describes a single discrepancy between an actual and an
+// expected JSON payload, as found by Compare.
+type Difference struct {
+	// Path is the location of the difference within the payload, e.g.
+	// "$.foo[1].bar".
+	Path string
+	// Kind categorizes the difference.
+	Kind DifferenceKind
+	// Expected is the expected value at Path, as decoded by encoding/json.
+	// Numbers are decoded into json.Numbers to retain their precision.
+	// For key and length differences this holds the offending keys or
+	// lengths instead.
+	Expected interface{}
+	// Actual is the actual value at Path, decoded in the same way as
+	// Expected. For key and length differences this holds the offending keys
+	// or lengths instead.
+	Actual interface{}
+
+	// msgs holds the human readable description of this difference, as
+	// given to the Printer by Assertf.
+	msgs []string
+}
+
+// String returns a human readable description of the difference.
+func (d Difference) String() string {
+	return strings.Join(d.msgs, "\n")
+}
@@ -0,0 +1,201 @@
+package jsonassert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// directivePattern matches named directives such as "<<PRESENCE>>", and
+// directives with arguments such as "<<GT:0>>".
+var directivePattern = regexp.MustCompile(`(?s)^<<([A-Z][A-Z0-9_]*)(?::(.*))?>>$`)
+
+// parseDirective splits a named directive into its name and arguments.
+func parseDirective(s string) (name, args string, ok bool) {
+	match := directivePattern.FindStringSubmatch(s)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// valueDirective verifies that the actual value at the given path satisfies a
+// directive given in place of a value in the expected JSON, and reports any
+// differences. The actual value is decoded in the same way as Difference.Actual.
+type valueDirective func(c *comparer, path string, act interface{}, args string)
+
+var valueDirectives map[string]valueDirective
+
+func init() {
+	valueDirectives = map[string]valueDirective{
+		"PRESENCE": checkPresence,
+		"ABSENT":   checkAbsent,
+		"OPTIONAL": func(*comparer, string, interface{}, string) {},
+		"NULLABLE": checkNullable,
+		"STRING":   typeDirective(jsonString, "a string"),
+		"NUMBER":   typeDirective(jsonNumber, "a number"),
+		"INTEGER":  checkInteger,
+		"BOOLEAN":  typeDirective(jsonBoolean, "a boolean"),
+		"OBJECT":   typeDirective(jsonObject, "an object"),
+		"ARRAY":    typeDirective(jsonArray, "an array"),
+		"UUID":     formatDirective("a UUID", validateUUID),
+		"RFC3339":  formatDirective("an RFC 3339 timestamp", validateRFC3339),
+		"EMAIL":    formatDirective("an email address", validateEmail),
+		"URI":      formatDirective("an absolute URI", validateURI),
+		"IP":       formatDirective("an IP address", validateIP),
+		"IPV4":     formatDirective("an IPv4 address", validateIPv4),
+		"IPV6":     formatDirective("an IPv6 address", validateIPv6),
+		"DURATION": formatDirective("an ISO 8601 duration", validateDuration),
+
+		"TIME":        checkTime,
+		"TIME_WITHIN": checkTimeWithin,
+		"TIME_RANGE":  checkTimeRange,
+
+		"GT":    boundDirective("GT", "greater than", func(cmp int) bool { return cmp > 0 }),
+		"GTE":   boundDirective("GTE", "greater than or equal to", func(cmp int) bool { return cmp >= 0 }),
+		"LT":    boundDirective("LT", "less than", func(cmp int) bool { return cmp < 0 }),
+		"LTE":   boundDirective("LTE", "less than or equal to", func(cmp int) bool { return cmp <= 0 }),
+		"RANGE": checkRange,
+
+		"LEN":       lengthDirective("LEN"),
+		"MIN_LEN":   lengthDirective("MIN_LEN"),
+		"MAX_LEN":   lengthDirective("MAX_LEN"),
+		"NOT_EMPTY": lengthDirective("NOT_EMPTY"),
+
+		"CAPTURE": checkCapture,
+		"SAME":    checkSame,
+	}
+}
+
+// reportUnknownDirective reports a named directive in the expected JSON that is
+// neither built in nor registered, which is most likely a typo.
+func (c *comparer) reportUnknownDirective(path string, act interface{}, directive, name string) {
+	if reservedDirectives[name] {
+		c.report(DirectiveFailure, path, act, directive,
+			fmt.Sprintf("directive '%s' at '%s' cannot be used in place of a value", directive, path))
+		return
+	}
+	c.report(DirectiveFailure, path, act, directive,
+		fmt.Sprintf("unknown directive '%s' at '%s'; use \"<<REGEX:...>>\" to match a regular expression", directive, path))
+}
+
+// reportInvalidDirective reports a directive in the expected JSON whose
+// arguments could not be understood.
+func (c *comparer) reportInvalidDirective(path string, act interface{}, name, args string, err error) {
+	directive := fmt.Sprintf("<<%s:%s>>", name, args)
+	c.report(DirectiveFailure, path, act, directive,
+		fmt.Sprintf("invalid directive '%s' at '%s': %s", directive, path, err.Error()))
+}
+
+func checkPresence(c *comparer, path string, act interface{}, _ string) {
+	// Missing keys are reported by checkObject, so we only need to worry
+	// about nulls here.
+	if act == nil {
+		c.report(DirectiveFailure, path, nil, "<<PRESENCE>>",
+			fmt.Sprintf(`expected the presence of any value at '%s', but was null`, path))
+	}
+}
+
+// checkNullable accepts null, or any value that matches the directive or JSON
+// literal given as its argument, e.g. "<<NULLABLE:<<STRING>>>>" or
+// "<<NULLABLE:5>>". Without an argument, any value is accepted.
+func checkNullable(c *comparer, path string, act interface{}, args string) {
+	if act == nil || args == "" {
+		return
+	}
+	if strings.HasPrefix(args, "<<") {
+		c.pathassertf(path, serialize(act), serialize(args))
+		return
+	}
+	var literal interface{}
+	if err := unmarshal(args, &literal); err != nil {
+		c.reportInvalidDirective(path, act, "NULLABLE", args,
+			errors.New(`expected a directive or a JSON literal, use {"<<NULLABLE>>": ...} to wrap other values`))
+		return
+	}
+	c.pathassertf(path, serialize(act), serialize(literal))
+}
+
+func checkAbsent(c *comparer, path string, act interface{}, _ string) {
+	// Keys that are absent never make it this far, see checkObject.
+	c.report(ExtraKey, path, act, "<<ABSENT>>",
+		fmt.Sprintf(`expected no value at '%s', but found %s`, path, serialize(act)))
+}
+
+// wrapperDirectives are the directives that may be given as the only key of an
+// expected object, in order to modify how the key's value is compared against
+// the actual value. E.g.
+//
+//	{"<<OPTIONAL>>": {"id": "<<PRESENCE>>"}}
+var wrapperDirectives = map[string]bool{
+	"<<OPTIONAL>>": true,
+	"<<NULLABLE>>": true,
+}
+
+// unwrap returns the wrapper directive and the wrapped expected value, if the
+// given expected value is a wrapper object.
+func unwrap(exp interface{}) (directive string, wrapped interface{}, ok bool) {
+	obj, isObject := exp.(map[string]interface{})
+	if !isObject || len(obj) != 1 {
+		return "", nil, false
+	}
+	for key, val := range obj {
+		if wrapperDirectives[key] {
+			return key, val, true
+		}
+	}
+	return "", nil, false
+}
+
+// mayBeMissing reports whether the expected value of an object key allows the
+// key to be missing from the actual object.
+func mayBeMissing(exp interface{}) bool {
+	if exp == "<<ABSENT>>" || exp == "<<OPTIONAL>>" {
+		return true
+	}
+	directive, _, ok := unwrap(exp)
+	return ok && directive == "<<OPTIONAL>>"
+}
+
+func typeDirective(want jsonType, description string) valueDirective {
+	return func(c *comparer, path string, act interface{}, _ string) {
+		if got := typeOf(act); got != want {
+			c.report(TypeMismatch, path, act, nil,
+				fmt.Sprintf("expected %s at '%s' but found %s", description, path, got))
+		}
+	}
+}
+
+func checkInteger(c *comparer, path string, act interface{}, _ string) {
+	n, ok := act.(json.Number)
+	if !ok {
+		c.report(TypeMismatch, path, act, nil,
+			fmt.Sprintf("expected an integer at '%s' but found %s", path, typeOf(act)))
+		return
+	}
+	if !isInteger(n) {
+		c.report(TypeMismatch, path, act, nil,
+			fmt.Sprintf("expected an integer at '%s' but found '%s'", path, n))
+	}
+}
+
+// typeOf finds the JSON type of a decoded value.
+func typeOf(v interface{}) jsonType {
+	switch v.(type) {
+	case nil:
+		return jsonNull
+	case string:
+		return jsonString
+	case json.Number, float64:
+		return jsonNumber
+	case bool:
+		return jsonBoolean
+	case map[string]interface{}:
+		return jsonObject
+	case []interface{}:
+		return jsonArray
+	}
+	return jsonTypeUnknown
+}
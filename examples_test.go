@@ -55,3 +55,15 @@ func ExampleAsserter_Assertf_unorderedArray() {
 	//actual JSON at '$[0]' contained an unexpected element: "zero"
 	//expected JSON at '$[2]': "three" was missing from actual payload
 }
+
+func ExampleCompare() {
+	diffs, _ := jsonassert.Compare(
+		`{"name": "Jayne Cobb", "age": 36}`,
+		`{"name": "Jayne Cobb", "age": 37}`,
+	)
+	for _, diff := range diffs {
+		fmt.Printf("%s at %s: %s\n", diff.Kind, diff.Path, diff)
+	}
+	//output:
+	//value mismatch at $.age: expected number at '$.age' to be '37.0000000' but was '36.0000000'
+}
@@ -41,7 +41,10 @@ the payload, but will ignore the order in which they appear.
 package jsonassert
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 )
 
 // Printer is any type that has a testing.T-like Errorf function.
@@ -55,6 +58,11 @@ type Printer interface {
 // See Asserter.Assertf for the main use of this package.
 type Asserter struct {
 	tt
+	cfg config
+
+	// captured holds the values captured by "<<CAPTURE:name>>", see
+	// Asserter.Captured.
+	captured map[string]interface{}
 }
 
 /*
@@ -65,8 +73,12 @@ In most cases, this will look something like
 
 	ja := jsonassert.New(t)
 
+The behavior of the Asserter may be customized by passing in any number of
+Options after the Printer:
+
+	ja := jsonassert.New(t, jsonassert.WithTolerance(0.01))
 */
-func New(p Printer) *Asserter {
+func New(p Printer, opts ...Option) *Asserter {
 	// Initially this package was written without the assumption that the
 	// provided Printer will implement testing.tt, which includes the Helper()
 	// function to get better stacktraces in your testing utility functions.
@@ -77,10 +89,11 @@ func New(p Printer) *Asserter {
 	// printers that do not implement Helper(). This is done by wrapping the
 	// provided Printer into another struct that implements a NOOP Helper
 	// method.
+	cfg := newConfig(opts)
 	if t, ok := p.(tt); ok {
-		return &Asserter{tt: t}
+		return &Asserter{tt: t, cfg: cfg}
 	}
-	return &Asserter{tt: &noopHelperTT{Printer: p}}
+	return &Asserter{tt: &noopHelperTT{Printer: p}, cfg: cfg}
 }
 
 /*
@@ -123,8 +136,149 @@ payload, you can ignore the ordering:
 
 The above will verify that "foo", "bar", and "baz" are exactly the elements in
 the payload, but will ignore the order in which they appear.
+
+Assertf returns true if the payloads matched, and false if any discrepancies
+were reported. This allows you to e.g. skip checks that depend on the payload:
+
+	if ja.Assertf(body, `{"id": "<<PRESENCE>>"}`) {
+		// make further requests using the ID
+	}
+*/
+func (a *Asserter) Assertf(actualJSON, expectedJSON string, fmtArgs ...interface{}) bool {
+	a.tt.Helper()
+	diffs, err := a.compare(actualJSON, fmt.Sprintf(expectedJSON, fmtArgs...))
+	return a.printDifferences(diffs, err)
+}
+
+// AssertBytes works like Assertf, but takes the 'actual' JSON as a []byte,
+// e.g. as read from a response body.
+func (a *Asserter) AssertBytes(actualJSON []byte, expectedJSON string, fmtArgs ...interface{}) bool {
+	a.tt.Helper()
+	return a.Assertf(string(actualJSON), expectedJSON, fmtArgs...)
+}
+
+// AssertReader works like Assertf, but reads the 'actual' JSON from the given
+// io.Reader. Failing to read from the reader is reported as a discrepancy.
+func (a *Asserter) AssertReader(actualJSON io.Reader, expectedJSON string, fmtArgs ...interface{}) bool {
+	a.tt.Helper()
+	b, err := ioutil.ReadAll(actualJSON)
+	if err != nil {
+		a.tt.Errorf("unable to read 'actual' JSON: %s", err.Error())
+		return false
+	}
+	return a.Assertf(string(b), expectedJSON, fmtArgs...)
+}
+
+/*
+AssertValue works like Assertf, but takes any Go value as the 'actual' JSON,
+which is marshalled with encoding/json before the comparison. This lets you
+make assertions against structs, maps or json.RawMessages directly:
+
+	ja.AssertValue(user, `{"name": "River Tam", "age": 16}`)
+
+Note that strings are marshalled into JSON strings, so use Assertf if you
+already have a JSON payload as a string. Failing to marshal the value is
+reported as a discrepancy.
+*/
+func (a *Asserter) AssertValue(actual interface{}, expectedJSON string, fmtArgs ...interface{}) bool {
+	a.tt.Helper()
+	b, err := json.Marshal(actual)
+	if err != nil {
+		a.tt.Errorf("unable to marshal 'actual' value of type %T to JSON: %s", actual, err.Error())
+		return false
+	}
+	return a.Assertf(string(b), expectedJSON, fmtArgs...)
+}
+
+/*
+Requiref works exactly like Assertf, but stops the test after all the
+discrepancies have been reported. This is useful when subsequent steps of your
+test depend on the payload being correct:
+
+	ja.Requiref(body, `{"user": {"id": "<<PRESENCE>>"}}`)
+
+The test is stopped by calling FailNow() on the Printer, which *testing.T
+supports. If the Printer has no FailNow() method, the discrepancies are only
+reported, the test carries on, and Requiref returns false like Assertf would.
 */
-func (a *Asserter) Assertf(actualJSON, expectedJSON string, fmtArgs ...interface{}) {
+func (a *Asserter) Requiref(actualJSON, expectedJSON string, fmtArgs ...interface{}) bool {
+	a.tt.Helper()
+	diffs, err := a.compare(actualJSON, fmt.Sprintf(expectedJSON, fmtArgs...))
+	if !a.printDifferences(diffs, err) {
+		a.failNow()
+		return false
+	}
+	return true
+}
+
+// printDifferences gives the error or differences to the Printer, and reports
+// whether there was nothing to print.
+func (a *Asserter) printDifferences(diffs []Difference, err error) bool {
 	a.tt.Helper()
-	a.pathassertf("$", actualJSON, fmt.Sprintf(expectedJSON, fmtArgs...))
+	if err != nil {
+		a.tt.Errorf("%s", err.Error())
+		return false
+	}
+	for _, diff := range diffs {
+		for _, msg := range diff.msgs {
+			a.tt.Errorf("%s", msg)
+		}
+	}
+	return len(diffs) == 0
+}
+
+/*
+Compare compares the 'actual' JSON against the 'expected' JSON in the same way
+as Asserter.Assertf, but rather than reporting the discrepancies to a Printer,
+they are returned as a slice of Differences. This makes it possible to use
+this package outside of tests, e.g. in CLIs or linters.
+The expected JSON may contain the same directives as with Assertf, but is not
+treated as a format string.
+An error is returned only if either of the payloads is not valid JSON. If the
+payloads are semantically equal, the returned slice is empty.
+
+	diffs, err := jsonassert.Compare(`{"hello": "world"}`, `{"hello": "<<PRESENCE>>"}`)
+*/
+func Compare(actualJSON, expectedJSON string, opts ...Option) ([]Difference, error) {
+	cfg := newConfig(opts)
+	return compare(&cfg, actualJSON, expectedJSON)
+}
+
+func compare(cfg *config, actualJSON, expectedJSON string) ([]Difference, error) {
+	return newComparer(cfg).compare(actualJSON, expectedJSON)
+}
+
+// compare works like the package level compare, but compares against and
+// keeps track of the values captured by the Asserter's assertions.
+func (a *Asserter) compare(actualJSON, expectedJSON string) ([]Difference, error) {
+	c := newComparer(&a.cfg)
+	c.captured = a.captured
+	diffs, err := c.compare(actualJSON, expectedJSON)
+	for name, v := range c.newlyCaptured() {
+		if a.captured == nil {
+			a.captured = map[string]interface{}{}
+		}
+		a.captured[name] = v
+	}
+	return diffs, err
+}
+
+func (c *comparer) compare(actualJSON, expectedJSON string) ([]Difference, error) {
+	if c.cfg.directives && containsDirective(decode(expectedJSON), "SAME") {
+		// Collect the captures in a separate comparison first, so that
+		// "<<SAME:name>>" knows what to compare against wherever it is.
+		collector := newComparer(c.cfg)
+		collector.captured = c.captured
+		collector.pathassertf("$", actualJSON, expectedJSON)
+		if collector.err != nil {
+			return nil, collector.err
+		}
+		c.references = collector.collectReferences()
+	}
+	c.pathassertf("$", actualJSON, expectedJSON)
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.checkCaptures()
+	return c.diffs, nil
 }
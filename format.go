@@ -0,0 +1,149 @@
+package jsonassert
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// formatDirective creates a directive that verifies that the actual value is a
+// string in the given format, as validated by the given function.
+func formatDirective(description string, validate func(string) error) valueDirective {
+	return func(c *comparer, path string, act interface{}, _ string) {
+		s, ok := act.(string)
+		if !ok {
+			c.report(TypeMismatch, path, act, nil,
+				fmt.Sprintf("expected %s at '%s' but found %s", description, path, typeOf(act)))
+			return
+		}
+		if err := validate(s); err != nil {
+			c.report(DirectiveFailure, path, act, nil,
+				fmt.Sprintf("expected %s at '%s' but found '%s': %s", description, path, s, err.Error()))
+		}
+	}
+}
+
+func validateUUID(s string) error {
+	if len(s) != 36 {
+		return fmt.Errorf("expected 36 characters but found %d", len(s))
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return fmt.Errorf("expected '-' at position %d", i)
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return fmt.Errorf("expected a hexadecimal digit at position %d", i)
+			}
+		}
+	}
+	return nil
+}
+
+func validateRFC3339(s string) error {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err
+}
+
+func validateEmail(s string) error {
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return err
+	}
+	if addr.Name != "" || addr.Address != s {
+		return errors.New("expected a bare address without a display name")
+	}
+	return nil
+}
+
+func validateURI(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" {
+		return errors.New("missing scheme")
+	}
+	return nil
+}
+
+func validateIP(s string) error {
+	if net.ParseIP(s) == nil {
+		return errors.New("not an IPv4 or IPv6 address")
+	}
+	return nil
+}
+
+func validateIPv4(s string) error {
+	if net.ParseIP(s) == nil || strings.Contains(s, ":") {
+		return errors.New("not an IPv4 address")
+	}
+	return nil
+}
+
+func validateIPv6(s string) error {
+	if net.ParseIP(s) == nil || !strings.Contains(s, ":") {
+		return errors.New("not an IPv6 address")
+	}
+	return nil
+}
+
+// validateDuration validates ISO 8601 durations such as "P1Y2M3DT4H5M6.5S"
+// and "P2W".
+func validateDuration(s string) error {
+	if !strings.HasPrefix(s, "P") {
+		return errors.New("expected the duration to start with 'P'")
+	}
+	rest := s[1:]
+	// The designators that may follow a number, in the order they must
+	// appear in, before and after the 'T' separating the date from the time.
+	designators := "YMWD"
+	components := 0
+	inTime := false
+	for rest != "" {
+		if rest[0] == 'T' {
+			if inTime {
+				return errors.New("unexpected second 'T'")
+			}
+			if len(rest) == 1 {
+				return errors.New("expected at least one time component after 'T'")
+			}
+			inTime, designators, rest = true, "HMS", rest[1:]
+			continue
+		}
+		n, fraction := 0, false
+		for ; n < len(rest); n++ {
+			if (rest[n] == '.' || rest[n] == ',') && n > 0 && !fraction {
+				fraction = true
+			} else if !isDigit(rest[n]) {
+				break
+			}
+		}
+		if n == 0 || !isDigit(rest[n-1]) {
+			return fmt.Errorf("expected a number in '%s'", rest)
+		}
+		if n == len(rest) {
+			return fmt.Errorf("missing designator after '%s'", rest)
+		}
+		i := strings.IndexByte(designators, rest[n])
+		if i == -1 {
+			return fmt.Errorf("unexpected designator '%c'", rest[n])
+		}
+		designators, rest = designators[i+1:], rest[n+1:]
+		components++
+	}
+	if components == 0 {
+		return errors.New("expected at least one component")
+	}
+	return nil
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
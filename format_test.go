@@ -0,0 +1,75 @@
+package jsonassert
+
+import "testing"
+
+func TestValidateFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		valid    []string
+		invalid  []string
+	}{
+		{
+			name:     "UUID",
+			validate: validateUUID,
+			valid:    []string{"94ae1a31-63b2-4a55-a478-47764b60c56b", "94AE1A31-63B2-4A55-A478-47764B60C56B"},
+			invalid:  []string{"", "94ae1a3163b24a55a47847764b60c56b", "94ae1a31-63b2-4a55-a478-47764b60c56", "g4ae1a31-63b2-4a55-a478-47764b60c56b", "94ae1a31_63b2-4a55-a478-47764b60c56b"},
+		},
+		{
+			name:     "RFC3339",
+			validate: validateRFC3339,
+			valid:    []string{"2019-01-28T21:19:42Z", "2019-01-28T21:19:42.123456789+09:00"},
+			invalid:  []string{"2019-01-28", "2019-01-28T21:19:42", "2019-01-28 21:19:42Z", "2019-13-28T21:19:42Z"},
+		},
+		{
+			name:     "email",
+			validate: validateEmail,
+			valid:    []string{"river@serenity.space", "river.tam+alias@example.com"},
+			invalid:  []string{"", "river", "river@", "River Tam <river@serenity.space>", " river@serenity.space"},
+		},
+		{
+			name:     "URI",
+			validate: validateURI,
+			valid:    []string{"https://example.com/path?q=1#frag", "urn:isbn:0451450523", "mailto:river@serenity.space"},
+			invalid:  []string{"", "/relative/path", "example.com", "http://[::1"},
+		},
+		{
+			name:     "IP",
+			validate: validateIP,
+			valid:    []string{"127.0.0.1", "::1", "2001:db8::68"},
+			invalid:  []string{"", "256.0.0.1", "localhost", "1.2.3"},
+		},
+		{
+			name:     "IPv4",
+			validate: validateIPv4,
+			valid:    []string{"127.0.0.1", "192.168.0.255"},
+			invalid:  []string{"::1", "::ffff:127.0.0.1", "1.2.3"},
+		},
+		{
+			name:     "IPv6",
+			validate: validateIPv6,
+			valid:    []string{"::1", "2001:db8::68", "::ffff:127.0.0.1"},
+			invalid:  []string{"127.0.0.1", "2001:db8:::68"},
+		},
+		{
+			name:     "duration",
+			validate: validateDuration,
+			valid:    []string{"P1Y2M3DT4H5M6S", "P2W", "PT0.5S", "P1DT12H", "PT36H", "P0,5Y"},
+			invalid:  []string{"", "P", "PT", "1D", "P1H", "PT1D", "P1D2Y", "P1.5.5D", "P.5D", "PD", "P1", "P1DT1HT1M"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, s := range tt.valid {
+				if err := tt.validate(s); err != nil {
+					t.Errorf("expected '%s' to be valid but got error: %v", s, err)
+				}
+			}
+			for _, s := range tt.invalid {
+				if err := tt.validate(s); err == nil {
+					t.Errorf("expected '%s' to be invalid", s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package jsonassert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// updateGoldenEnvVar is the environment variable that, when set to a true
+// value such as "1" or "true", makes AssertGolden rewrite golden files. This is
+// not a flag, as flags would be added to every binary importing this package.
+const updateGoldenEnvVar = "JSONASSERT_UPDATE"
+
+/*
+AssertGolden compares the 'actual' JSON against the contents of the golden
+file at the given path, in the same way as Assertf. The golden file may contain
+directives like "<<PRESENCE>>", but is not treated as a format string.
+
+	ja.AssertGolden(body, "testdata/user.golden.json")
+
+When the tests are run with the JSONASSERT_UPDATE environment variable set to
+true, the golden file is (re)written from the actual payload instead:
+
+	JSONASSERT_UPDATE=true go test ./...
+
+Any directives in an existing golden file are preserved when they still match
+the actual payload. Everything else is replaced by the actual values.
+*/
+func (a *Asserter) AssertGolden(actualJSON, goldenPath string) bool {
+	a.tt.Helper()
+	if shouldUpdateGolden() {
+		if err := a.writeGolden(actualJSON, goldenPath); err != nil {
+			a.tt.Errorf("unable to update golden file '%s': %s", goldenPath, err.Error())
+			return false
+		}
+		return true
+	}
+
+	expectedJSON, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		a.tt.Errorf("unable to read golden file '%s': %s", goldenPath, err.Error())
+		return false
+	}
+	diffs, err := a.compare(actualJSON, string(expectedJSON))
+	if !a.printDifferences(diffs, err) {
+		a.tt.Errorf("golden file '%s' did not match, run the tests with %s=true to update it", goldenPath, updateGoldenEnvVar)
+		return false
+	}
+	return true
+}
+
+func shouldUpdateGolden() bool {
+	update, _ := strconv.ParseBool(os.Getenv(updateGoldenEnvVar))
+	return update
+}
+
+func (a *Asserter) writeGolden(actualJSON, goldenPath string) error {
+	var act interface{}
+	if err := unmarshal(actualJSON, &act); err != nil {
+		return fmt.Errorf("'actual' JSON is not valid JSON: %w", err)
+	}
+
+	// Keep whatever still matches from the previous golden file, so that
+	// directives aren't lost when the file is regenerated.
+	golden := act
+	if previous, err := ioutil.ReadFile(goldenPath); err == nil {
+		var exp interface{}
+		if err := unmarshal(string(previous), &exp); err == nil {
+			golden = a.mergeGolden(act, exp)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(golden); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(goldenPath, buf.Bytes(), 0o644)
+}
+
+// mergeGolden returns the expected value if it still matches the actual
+// value, and otherwise the actual value, merged recursively for objects and
+// arrays of equal length.
+func (a *Asserter) mergeGolden(act, exp interface{}) interface{} {
+	if diffs, err := compare(&a.cfg, serialize(act), serialize(exp)); err == nil && len(diffs) == 0 {
+		return exp
+	}
+	switch act := act.(type) {
+	case map[string]interface{}:
+		if exp, ok := exp.(map[string]interface{}); ok {
+			merged := make(map[string]interface{}, len(act))
+			for key, actVal := range act {
+				if expVal, ok := exp[key]; ok {
+					merged[key] = a.mergeGolden(actVal, expVal)
+				} else {
+					merged[key] = actVal
+				}
+			}
+			// Keys that are only in the golden file may still match, such as
+			// "<<ABSENT>>" and "<<OPTIONAL>>" keys, and the extra keys marker.
+			if a.cfg.directives {
+				for key, expVal := range exp {
+					if _, ok := act[key]; !ok && (key == extraKeysAllowedKey || mayBeMissing(expVal)) {
+						merged[key] = expVal
+					}
+				}
+			}
+			return merged
+		}
+	case []interface{}:
+		// Arrays starting with a directive, such as "<<UNORDERED>>", don't
+		// line up element by element with the actual array.
+		if exp, ok := exp.([]interface{}); ok && len(exp) == len(act) && !startsWithDirective(exp) {
+			merged := make([]interface{}, len(act))
+			for i := range act {
+				merged[i] = a.mergeGolden(act[i], exp[i])
+			}
+			return merged
+		}
+	}
+	return act
+}
+
+func startsWithDirective(arr []interface{}) bool {
+	if len(arr) == 0 {
+		return false
+	}
+	s, ok := arr[0].(string)
+	if !ok {
+		return false
+	}
+	isDirective, _ := isRegEx(s)
+	return isDirective
+}
@@ -0,0 +1,154 @@
+package jsonassert_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubient/jsonassert"
+)
+
+func TestAssertGolden(t *testing.T) {
+	writeGolden := func(t *testing.T, contents string) string {
+		path := filepath.Join(t.TempDir(), "payload.golden.json")
+		if err := ioutil.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("compare", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			golden string
+			act    string
+			msgs   []string
+		}{
+			"matching payload": {
+				`{"id": "<<PRESENCE>>", "discount": "100%"}`,
+				`{"id": "abc", "discount": "100%"}`,
+				nil,
+			},
+			"different payload": {
+				`{"id": "<<PRESENCE>>", "name": "River Tam"}`,
+				`{"id": "abc", "name": "Simon Tam"}`,
+				[]string{
+					`expected string at '$.name' to be 'River Tam' but was 'Simon Tam'`,
+					`golden file 'GOLDEN' did not match, run the tests with JSONASSERT_UPDATE=true to update it`,
+				},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				path := writeGolden(t, tc.golden)
+				var msgs []string
+				for _, msg := range tc.msgs {
+					msgs = append(msgs, strings.Replace(msg, "GOLDEN", path, 1))
+				}
+				(&testCase{act: tc.act, exp: tc.golden, msgs: msgs}).checkAssertion(t, func(ja *jsonassert.Asserter) bool {
+					return ja.AssertGolden(tc.act, path)
+				})
+			})
+		}
+	})
+
+	t.Run("missing golden file", func(t *testing.T) {
+		tp := &testPrinter{}
+		path := filepath.Join(t.TempDir(), "missing.golden.json")
+		if jsonassert.New(tp).AssertGolden(`{}`, path) {
+			t.Errorf("expected false to be returned")
+		}
+		if len(tp.messages) != 1 {
+			t.Errorf("expected 1 assertion message but got %q", tp.messages)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		os.Setenv("JSONASSERT_UPDATE", "true")
+		defer os.Unsetenv("JSONASSERT_UPDATE")
+
+		for name, tc := range map[string]struct {
+			golden string
+			act    string
+			want   string
+		}{
+			"new golden file": {
+				``,
+				`{"b": [1, 2.50, 9007199254740993], "a": "<b>"}`,
+				`{
+  "a": "<b>",
+  "b": [
+    1,
+    2.50,
+    9007199254740993
+  ]
+}
+`,
+			},
+			"preserves matching directives": {
+				`{"id": "<<PRESENCE>>", "tags": ["<<UNORDERED>>", "a", "b"], "name": "<<^River>>", "age": 16}`,
+				`{"id": "xyz", "tags": ["b", "a"], "name": "Simon Tam", "age": 19}`,
+				`{
+  "age": 19,
+  "id": "<<PRESENCE>>",
+  "name": "Simon Tam",
+  "tags": [
+    "<<UNORDERED>>",
+    "a",
+    "b"
+  ]
+}
+`,
+			},
+			"preserves absent and optional keys": {
+				`{"<<EXTRA_KEYS_ALLOWED>>": true, "a": 1, "password": "<<ABSENT>>", "nickname": "<<OPTIONAL>>", "address": {"<<OPTIONAL>>": {"city": "<<STRING>>"}}}`,
+				`{"a": 2, "b": 3}`,
+				`{
+  "<<EXTRA_KEYS_ALLOWED>>": true,
+  "a": 2,
+  "address": {
+    "<<OPTIONAL>>": {
+      "city": "<<STRING>>"
+    }
+  },
+  "b": 3,
+  "nickname": "<<OPTIONAL>>",
+  "password": "<<ABSENT>>"
+}
+`,
+			},
+			"replaces directives that no longer match": {
+				`{"id": "<<PRESENCE>>", "items": [{"id": "<<PRESENCE>>", "n": 1}]}`,
+				`{"id": null, "items": [{"id": "a", "n": 2}]}`,
+				`{
+  "id": null,
+  "items": [
+    {
+      "id": "<<PRESENCE>>",
+      "n": 2
+    }
+  ]
+}
+`,
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "nested", "payload.golden.json")
+				if tc.golden != "" {
+					path = writeGolden(t, tc.golden)
+				}
+				tp := &testPrinter{}
+				if !jsonassert.New(tp).AssertGolden(tc.act, path) {
+					t.Errorf("expected true to be returned, got messages %q", tp.messages)
+				}
+				got, err := ioutil.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != tc.want {
+					t.Errorf("expected golden file to contain\n%s\nbut was\n%s", tc.want, got)
+				}
+			})
+		}
+	})
+}
@@ -12,3 +12,22 @@ type noopHelperTT struct {
 func (*noopHelperTT) Helper() {
 	// Intentional NOOP
 }
+
+// failNower is implemented by *testing.T, and any other Printer that is able
+// to stop the current test.
+type failNower interface {
+	FailNow()
+}
+
+// failNow stops the current test if the Printer supports it. Printers that do
+// not implement FailNow() are left alone, in which case Requiref behaves
+// exactly like Assertf.
+func (a *Asserter) failNow() {
+	var p Printer = a.tt
+	if wrapped, ok := a.tt.(*noopHelperTT); ok {
+		p = wrapped.Printer
+	}
+	if f, ok := p.(failNower); ok {
+		f.FailNow()
+	}
+}
@@ -0,0 +1,85 @@
+package jsonassert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxBodyLength is the number of characters of a non-JSON response body that
+// is included in failure messages.
+const maxBodyLength = 200
+
+/*
+AssertResponse makes assertions against an HTTP response: it checks that the
+status code is the wanted status, that the Content-Type is a JSON media type
+(i.e. "application/json" or any "+json" type), and then compares the body
+against the expected JSON in the same way as Assertf. The body is read and
+closed.
+
+	ja.AssertResponse(resp, http.StatusOK, `{"name": "%s"}`, "River Tam")
+
+When testing handlers with an *httptest.ResponseRecorder, pass in the result of
+its Result() method:
+
+	ja.AssertResponse(rec.Result(), http.StatusCreated, `{"id": "<<PRESENCE>>"}`)
+*/
+func (a *Asserter) AssertResponse(resp *http.Response, wantStatus int, expectedJSON string, fmtArgs ...interface{}) bool {
+	a.tt.Helper()
+	if resp == nil {
+		a.tt.Errorf("expected an HTTP response with status %s but got nil", statusString(wantStatus))
+		return false
+	}
+
+	var body []byte
+	if resp.Body != nil {
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			a.tt.Errorf("unable to read HTTP response body: %s", err.Error())
+			return false
+		}
+		body = b
+	}
+
+	ok := true
+	if resp.StatusCode != wantStatus {
+		a.tt.Errorf("expected HTTP status %s but got %s", statusString(wantStatus), statusString(resp.StatusCode))
+		ok = false
+	}
+	if contentType := resp.Header.Get("Content-Type"); !isJSONMediaType(contentType) {
+		a.tt.Errorf("expected a JSON Content-Type but got '%s'", contentType)
+		ok = false
+	}
+	if !json.Valid(body) {
+		a.tt.Errorf("HTTP response body with status %s is not valid JSON: '%s'", statusString(resp.StatusCode), truncate(string(body), maxBodyLength))
+		return false
+	}
+	return a.AssertBytes(body, expectedJSON, fmtArgs...) && ok
+}
+
+func statusString(code int) string {
+	if text := http.StatusText(code); text != "" {
+		return fmt.Sprintf("%d %s", code, text)
+	}
+	return fmt.Sprintf("%d", code)
+}
+
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length]) + "..."
+}
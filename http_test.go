@@ -0,0 +1,84 @@
+package jsonassert_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubient/jsonassert"
+)
+
+func TestAssertResponse(t *testing.T) {
+	respond := func(status int, contentType, body string) *http.Response {
+		rec := httptest.NewRecorder()
+		if contentType != "" {
+			rec.Header().Set("Content-Type", contentType)
+		}
+		rec.WriteHeader(status)
+		_, _ = rec.WriteString(body)
+		return rec.Result()
+	}
+
+	for name, tc := range map[string]struct {
+		resp *http.Response
+		exp  string
+		msgs []string
+	}{
+		"matching response": {
+			respond(http.StatusOK, "application/json", `{"hello": "world"}`),
+			`{"hello": "world"}`,
+			nil,
+		},
+		"JSON media type with parameters": {
+			respond(http.StatusOK, "application/json; charset=utf-8", `[]`),
+			`[]`,
+			nil,
+		},
+		"JSON suffixed media type": {
+			respond(http.StatusOK, "application/problem+json", `{}`),
+			`{}`,
+			nil,
+		},
+		"different status": {
+			respond(http.StatusNotFound, "application/json", `{"hello": "world"}`),
+			`{"hello": "world"}`,
+			[]string{`expected HTTP status 200 OK but got 404 Not Found`},
+		},
+		"different body": {
+			respond(http.StatusOK, "application/json", `{"hello": "world"}`),
+			`{"hello": "世界"}`,
+			[]string{`expected string at '$.hello' to be '世界' but was 'world'`},
+		},
+		"non-JSON content type": {
+			respond(http.StatusOK, "text/plain", `{}`),
+			`{}`,
+			[]string{`expected a JSON Content-Type but got 'text/plain'`},
+		},
+		"missing content type and non-JSON body": {
+			respond(http.StatusInternalServerError, "", `internal server error`),
+			`{}`,
+			[]string{
+				`expected HTTP status 200 OK but got 500 Internal Server Error`,
+				`expected a JSON Content-Type but got ''`,
+				`HTTP response body with status 500 Internal Server Error is not valid JSON: 'internal server error'`,
+			},
+		},
+		"long non-JSON body": {
+			respond(http.StatusOK, "application/json", strings.Repeat("a", 300)),
+			`{}`,
+			[]string{`HTTP response body with status 200 OK is not valid JSON: '` + strings.Repeat("a", 200) + `...'`},
+		},
+		"nil response": {
+			nil,
+			`{}`,
+			[]string{`expected an HTTP response with status 200 OK but got nil`},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			(&testCase{msgs: tc.msgs}).checkAssertion(t, func(ja *jsonassert.Asserter) bool {
+				return ja.AssertResponse(tc.resp, http.StatusOK, tc.exp)
+			})
+		})
+	}
+}
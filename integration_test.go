@@ -1,9 +1,14 @@
 package jsonassert_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/kubient/jsonassert"
 )
@@ -29,6 +34,38 @@ func TestAssertf(t *testing.T) {
 			}
 		})
 
+		t.Run("large and precise numbers", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"equal large integers":               {`9007199254740993`, `9007199254740993`, nil},
+				"equal integers written differently": {`100`, `1e2`, nil},
+				"equal precise decimals":             {`0.1000000000000000000001`, `0.1000000000000000000001`, nil},
+				"equal decimals written differently": {`1.50`, `1.5`, nil},
+				"numbers out of float64 range":       {`1e400`, `1E+400`, nil},
+				"different large integers": {
+					`9007199254740993`,
+					`9007199254740992`,
+					[]string{`expected number at '$' to be '9007199254740992' but was '9007199254740993'`},
+				},
+				"different nested large integers": {
+					`{"ids": [9007199254740993]}`,
+					`{"ids": [9007199254740992]}`,
+					[]string{`expected number at '$.ids[0]' to be '9007199254740992' but was '9007199254740993'`},
+				},
+				"different precise decimals": {
+					`12345678901234567.89`,
+					`12345678901234567.88`,
+					[]string{`expected number at '$' to be '12345678901234567.88' but was '12345678901234567.89'`},
+				},
+				"tiny differences": {
+					`0.0000000001`,
+					`0`,
+					[]string{`expected number at '$' to be '0' but was '0.0000000001'`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
 		t.Run("difference", func(t *testing.T) {
 			for name, tc := range map[string]*testCase{
 				"types":                    {`"true"`, `true`, []string{`actual JSON (string) and expected JSON (boolean) were of different types at '$'`}},
@@ -110,7 +147,7 @@ func TestAssertf(t *testing.T) {
 				"presence against null": {
 					`{"foo": null}`,
 					`{"foo": "<<PRESENCE>>"}`,
-					[]string{`expected the presence of any value at '$.foo', but was absent`},
+					[]string{`expected the presence of any value at '$.foo', but was null`},
 				},
 				"presence against boolean": {
 					`{"foo": true}`,
@@ -142,6 +179,529 @@ func TestAssertf(t *testing.T) {
 			}
 		})
 
+		t.Run("with TYPE directives", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"string":           {`{"a": "hello"}`, `{"a": "<<STRING>>"}`, nil},
+				"empty string":     {`{"a": ""}`, `{"a": "<<STRING>>"}`, nil},
+				"number":           {`{"a": -1.5e3}`, `{"a": "<<NUMBER>>"}`, nil},
+				"integer":          {`{"a": 42}`, `{"a": "<<INTEGER>>"}`, nil},
+				"integral number":  {`{"a": 4.2e1}`, `{"a": "<<INTEGER>>"}`, nil},
+				"boolean":          {`{"a": false}`, `{"a": "<<BOOLEAN>>"}`, nil},
+				"object":           {`{"a": {}}`, `{"a": "<<OBJECT>>"}`, nil},
+				"array":            {`{"a": [1, "b"]}`, `{"a": "<<ARRAY>>"}`, nil},
+				"nested in arrays": {`[1, "b", true]`, `["<<NUMBER>>", "<<STRING>>", "<<BOOLEAN>>"]`, nil},
+				"number instead of string": {
+					`{"a": 1}`,
+					`{"a": "<<STRING>>"}`,
+					[]string{`expected a string at '$.a' but found number`},
+				},
+				"string instead of number": {
+					`{"age": "16"}`,
+					`{"age": "<<NUMBER>>"}`,
+					[]string{`expected a number at '$.age' but found string`},
+				},
+				"null instead of boolean": {
+					`{"a": null}`,
+					`{"a": "<<BOOLEAN>>"}`,
+					[]string{`expected a boolean at '$.a' but found null`},
+				},
+				"array instead of object": {
+					`{"a": []}`,
+					`{"a": "<<OBJECT>>"}`,
+					[]string{`expected an object at '$.a' but found array`},
+				},
+				"object instead of array": {
+					`{"a": {}}`,
+					`{"a": "<<ARRAY>>"}`,
+					[]string{`expected an array at '$.a' but found object`},
+				},
+				"fraction instead of integer": {
+					`{"a": 1.5}`,
+					`{"a": "<<INTEGER>>"}`,
+					[]string{`expected an integer at '$.a' but found '1.5'`},
+				},
+				"string instead of integer": {
+					`{"a": "1"}`,
+					`{"a": "<<INTEGER>>"}`,
+					[]string{`expected an integer at '$.a' but found string`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("with FORMAT directives", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"all formats": {
+					`{"id": "94ae1a31-63b2-4a55-a478-47764b60c56b", "createdAt": "2019-01-28T21:19:42Z", "email": "river@serenity.space", "website": "https://serenity.space", "ip": "::1", "ipv4": "127.0.0.1", "ipv6": "::1", "ttl": "PT1H30M"}`,
+					`{"id": "<<UUID>>", "createdAt": "<<RFC3339>>", "email": "<<EMAIL>>", "website": "<<URI>>", "ip": "<<IP>>", "ipv4": "<<IPV4>>", "ipv6": "<<IPV6>>", "ttl": "<<DURATION>>"}`,
+					nil,
+				},
+				"invalid UUID": {
+					`{"id": "94ae1a31"}`,
+					`{"id": "<<UUID>>"}`,
+					[]string{`expected a UUID at '$.id' but found '94ae1a31': expected 36 characters but found 8`},
+				},
+				"invalid URI": {
+					`{"website": "serenity.space"}`,
+					`{"website": "<<URI>>"}`,
+					[]string{`expected an absolute URI at '$.website' but found 'serenity.space': missing scheme`},
+				},
+				"IPv6 instead of IPv4": {
+					`{"ip": "::1"}`,
+					`{"ip": "<<IPV4>>"}`,
+					[]string{`expected an IPv4 address at '$.ip' but found '::1': not an IPv4 address`},
+				},
+				"number instead of format": {
+					`{"ip": 127001}`,
+					`{"ip": "<<IP>>"}`,
+					[]string{`expected an IP address at '$.ip' but found number`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("with exponents too large to compare exactly", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"equal":                {`1e1000000000`, `1e1000000000`, nil},
+				"huge integer":         {`[1e9999999]`, `["<<INTEGER>>"]`, nil},
+				"huge number in range": {`[1e9999999]`, `["<<GT:0>>"]`, nil},
+				"different": {
+					`1e1000000000`,
+					`1`,
+					[]string{`expected number at '$' to be '1' but was '1e1000000000'`},
+				},
+				"tiny fraction": {
+					`[1e-9999999]`,
+					`["<<INTEGER>>"]`,
+					[]string{`expected an integer at '$[0]' but found '1e-9999999'`},
+				},
+				"outside range": {
+					`[-1e9999999]`,
+					`["<<RANGE:0..1>>"]`,
+					[]string{`expected a number between 0 and 1 at '$[0]' but found '-1e9999999'`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("with numeric comparison directives", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"greater than":           {`{"n": 1}`, `{"n": "<<GT:0>>"}`, nil},
+				"greater than or equal":  {`{"n": 1}`, `{"n": "<<GTE:1>>"}`, nil},
+				"less than":              {`{"n": 99.5}`, `{"n": "<<LT:100>>"}`, nil},
+				"less than or equal":     {`{"n": 1e2}`, `{"n": "<<LTE:100>>"}`, nil},
+				"within range":           {`{"n": 0.5}`, `{"n": "<<RANGE:0..1>>"}`, nil},
+				"range bounds inclusive": {`{"a": 0, "b": 1.0}`, `{"a": "<<RANGE:0..1>>", "b": "<<RANGE:0..1>>"}`, nil},
+				"large integers": {
+					`{"n": 12345678901234567891}`,
+					`{"n": "<<GT:12345678901234567890>>"}`,
+					nil,
+				},
+				"not greater than": {
+					`{"n": 0}`,
+					`{"n": "<<GT:0>>"}`,
+					[]string{`expected a number greater than 0 at '$.n' but found '0'`},
+				},
+				"not less than or equal": {
+					`{"n": 100.01}`,
+					`{"n": "<<LTE:100>>"}`,
+					[]string{`expected a number less than or equal to 100 at '$.n' but found '100.01'`},
+				},
+				"outside range": {
+					`{"n": -0.1}`,
+					`{"n": "<<RANGE:0..1>>"}`,
+					[]string{`expected a number between 0 and 1 at '$.n' but found '-0.1'`},
+				},
+				"not a number": {
+					`{"n": "1"}`,
+					`{"n": "<<GTE:1>>"}`,
+					[]string{`expected a number greater than or equal to 1 at '$.n' but found string`},
+				},
+				"invalid bound": {
+					`{"n": 1}`,
+					`{"n": "<<LT:ten>>"}`,
+					[]string{`invalid directive '<<LT:ten>>' at '$.n': 'ten' is not a number`},
+				},
+				"invalid range": {
+					`{"n": 1}`,
+					`{"n": "<<RANGE:1..0>>"}`,
+					[]string{`invalid directive '<<RANGE:1..0>>' at '$.n': expected 1 to be no greater than 0`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("with array length directives", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"exact length":            {`{"a": [1, 2, 3]}`, `{"a": "<<LEN:3>>"}`, nil},
+				"minimum length":          {`{"a": [1]}`, `{"a": "<<MIN_LEN:1>>"}`, nil},
+				"maximum length":          {`{"a": []}`, `{"a": "<<MAX_LEN:50>>"}`, nil},
+				"not empty":               {`{"a": [null]}`, `{"a": "<<NOT_EMPTY>>"}`, nil},
+				"with element template":   {`{"a": [{"id": 1}, {"id": 2}]}`, `{"a": ["<<MIN_LEN:1>>", {"id": "<<INTEGER>>"}]}`, nil},
+				"template on empty array": {`{"a": []}`, `{"a": ["<<MAX_LEN:2>>", "<<STRING>>"]}`, nil},
+				"wrong exact length": {
+					`{"a": [1, 2]}`,
+					`{"a": "<<LEN:3>>"}`,
+					[]string{`expected array at '$.a' to have exactly 3 element(s) but it had 2`},
+				},
+				"too short": {
+					`{"a": []}`,
+					`{"a": "<<MIN_LEN:1>>"}`,
+					[]string{`expected array at '$.a' to have at least 1 element(s) but it had 0`},
+				},
+				"too long": {
+					`{"a": [1, 2, 3]}`,
+					`{"a": ["<<MAX_LEN:2>>"]}`,
+					[]string{`expected array at '$.a' to have at most 2 element(s) but it had 3`},
+				},
+				"empty": {
+					`{"a": []}`,
+					`{"a": "<<NOT_EMPTY>>"}`,
+					[]string{`expected array at '$.a' to have at least 1 element(s) but it had 0`},
+				},
+				"elements not matching template": {
+					`{"a": [{"id": 1}, {"id": "2"}]}`,
+					`{"a": ["<<LEN:2>>", {"id": "<<INTEGER>>"}]}`,
+					[]string{`expected an integer at '$.a[1].id' but found string`},
+				},
+				"wrong length and elements": {
+					`{"a": ["x", 1]}`,
+					`{"a": ["<<MIN_LEN:3>>", "<<STRING>>"]}`,
+					[]string{
+						`expected array at '$.a' to have at least 3 element(s) but it had 2`,
+						`expected a string at '$.a[1]' but found number`,
+					},
+				},
+				"more than one template": {
+					`{"a": [1, 2]}`,
+					`{"a": ["<<LEN:2>>", 1, 2]}`,
+					[]string{`expected at most one element template after '<<LEN:2>>' at '$.a' but found 2`},
+				},
+				"not an array": {
+					`{"a": {}}`,
+					`{"a": "<<NOT_EMPTY>>"}`,
+					[]string{`expected an array at '$.a' but found object`},
+				},
+				"invalid length": {
+					`{"a": []}`,
+					`{"a": "<<LEN:-1>>"}`,
+					[]string{`invalid directive '<<LEN:-1>>' at '$.a': '-1' is not a valid length`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("with CAPTURE directives", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"same value": {
+					`{"order": {"id": "abc"}, "events": [{"order_id": "abc"}, {"order_id": "abc"}]}`,
+					`{"order": {"id": "<<CAPTURE:id>>"}, "events": [{"order_id": "<<SAME:id>>"}, {"order_id": "<<SAME:id>>"}]}`,
+					nil,
+				},
+				"same value before capture": {
+					`{"a": 1.0, "z": 1}`,
+					`{"a": "<<SAME:n>>", "z": "<<CAPTURE:n>>"}`,
+					nil,
+				},
+				"same composite value": {
+					`[{"a": [1, 2]}, {"a": [1, 2]}]`,
+					`["<<CAPTURE:obj>>", "<<SAME:obj>>"]`,
+					nil,
+				},
+				"different value": {
+					`{"order": {"id": "abc"}, "events": [{"order_id": "abc"}, {"order_id": "def"}]}`,
+					`{"order": {"id": "<<CAPTURE:id>>"}, "events": [{"order_id": "<<SAME:id>>"}, {"order_id": "<<SAME:id>>"}]}`,
+					[]string{`expected the value at '$.events[1].order_id' to be the same as the value captured as 'id' at '$.order.id', which was "abc", but was "def"`},
+				},
+				"captured twice with different values": {
+					`{"a": 1, "b": 2}`,
+					`{"a": "<<CAPTURE:n>>", "b": "<<CAPTURE:n>>"}`,
+					[]string{`expected the value at '$.b' to be the same as the value captured as 'n' at '$.a', which was 1, but was 2`},
+				},
+				"within unordered arrays": {
+					`{"id": 7, "items": [{"ref": 8}, {"ref": 7}]}`,
+					`{"id": "<<CAPTURE:id>>", "items": ["<<UNORDERED>>", {"ref": "<<SAME:id>>"}, {"ref": 8}]}`,
+					nil,
+				},
+				"different value within unordered arrays": {
+					`{"id": 7, "items": [{"ref": 8}, {"ref": 9}]}`,
+					`{"id": "<<CAPTURE:id>>", "items": ["<<UNORDERED>>", {"ref": "<<SAME:id>>"}, {"ref": 8}]}`,
+					[]string{
+						`actual JSON at '$.items[1]' did not match any expected element, differences to the closest expected element at '$.items[0]' follow`,
+						`expected the value at '$.items[1].ref' to be the same as the value captured as 'id' at '$.id', which was 7, but was 9`,
+					},
+				},
+				"capture within contained elements": {
+					`[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"ref": 3}]`,
+					`["<<CONTAINS>>", {"id": "<<CAPTURE:id>>", "name": "b"}, {"ref": "<<SAME:id>>"}]`,
+					[]string{`expected JSON at '$[1]': {"ref":"\u003c\u003cSAME:id\u003e\u003e"} was missing from actual payload`},
+				},
+				"same value among other contained elements": {
+					`{"id": 7, "items": [8, 7]}`,
+					`{"id": "<<CAPTURE:id>>", "items": ["<<CONTAINS>>", "<<SAME:id>>"]}`,
+					nil,
+				},
+				"same value matched regardless of template order": {
+					`{"id": 7, "items": [{"ref": 8}, {"ref": 7}]}`,
+					`{"id": "<<CAPTURE:id>>", "items": ["<<UNORDERED>>", {"ref": "<<SAME:id>>"}, {"ref": "<<PRESENCE>>"}]}`,
+					nil,
+				},
+				"same value matched in order": {
+					`{"id": 7, "items": [8, 7, 9]}`,
+					`{"id": "<<CAPTURE:id>>", "items": ["<<CONTAINS_IN_ORDER>>", "<<SAME:id>>", 9]}`,
+					nil,
+				},
+				"capture within unordered elements": {
+					`{"items": [{"id": 7, "kind": "b"}, {"id": 8, "kind": "a"}], "ref": 8}`,
+					`{"items": ["<<UNORDERED>>", {"id": "<<CAPTURE:id>>", "kind": "a"}, {"id": "<<PRESENCE>>", "kind": "b"}], "ref": "<<SAME:id>>"}`,
+					nil,
+				},
+				"nothing captured": {
+					`{"a": 1}`,
+					`{"a": "<<SAME:n>>"}`,
+					[]string{`expected the value at '$.a' to be the same as the value captured as 'n', but nothing was captured as 'n'`},
+				},
+				"missing name": {
+					`{"a": 1}`,
+					`{"a": "<<CAPTURE:>>"}`,
+					[]string{`invalid directive '<<CAPTURE:>>' at '$.a': expected a name`},
+				},
+				"first capture in document order": {
+					`[0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2]`,
+					`[0, 0, "<<CAPTURE:n>>", 0, 0, 0, 0, 0, 0, 0, "<<CAPTURE:n>>"]`,
+					[]string{`expected the value at '$[10]' to be the same as the value captured as 'n' at '$[2]', which was 1, but was 2`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("with TIME directives", func(t *testing.T) {
+			now := time.Date(2021, 11, 15, 9, 0, 0, 0, time.UTC)
+			clock := jsonassert.WithClock(func() time.Time { return now })
+			for name, tc := range map[string]*testCase{
+				"same instant in a different zone and precision": {
+					`{"t": "2021-11-15T18:00:00.000+09:00"}`,
+					`{"t": "<<TIME:2021-11-15T09:00:00Z>>"}`,
+					nil,
+				},
+				"different instant": {
+					`{"t": "2021-11-15T09:00:00+09:00"}`,
+					`{"t": "<<TIME:2021-11-15T09:00:00Z>>"}`,
+					[]string{`expected a timestamp at '$.t' representing the same instant as '2021-11-15T09:00:00Z' but found '2021-11-15T09:00:00+09:00'`},
+				},
+				"within window before now": {`{"t": "2021-11-15T08:59:56Z"}`, `{"t": "<<TIME_WITHIN:5s>>"}`, nil},
+				"within window after now":  {`{"t": "2021-11-15T18:00:04+09:00"}`, `{"t": "<<TIME_WITHIN:5s>>"}`, nil},
+				"outside window": {
+					`{"t": "2021-11-15T09:01:00Z"}`,
+					`{"t": "<<TIME_WITHIN:5s>>"}`,
+					[]string{`expected a timestamp within 5s of now (2021-11-15T09:00:00Z) at '$.t' but found '2021-11-15T09:01:00Z', which is 1m0s off`},
+				},
+				"within range": {
+					`{"t": "2021-11-15T09:00:00Z"}`,
+					`{"t": "<<TIME_RANGE:2021-01-01T00:00:00Z..2021-12-31T23:59:59Z>>"}`,
+					nil,
+				},
+				"outside range": {
+					`{"t": "2022-01-01T00:00:00Z"}`,
+					`{"t": "<<TIME_RANGE:2021-01-01T00:00:00Z..2021-12-31T23:59:59Z>>"}`,
+					[]string{`expected a timestamp between '2021-01-01T00:00:00Z' and '2021-12-31T23:59:59Z' at '$.t' but found '2022-01-01T00:00:00Z'`},
+				},
+				"not a timestamp": {
+					`{"t": 1637000000}`,
+					`{"t": "<<TIME_WITHIN:5s>>"}`,
+					[]string{`expected an RFC 3339 timestamp at '$.t' but found number`},
+				},
+				"invalid duration": {
+					`{"t": "2021-11-15T09:00:00Z"}`,
+					`{"t": "<<TIME_WITHIN:5 seconds>>"}`,
+					[]string{`invalid directive '<<TIME_WITHIN:5 seconds>>' at '$.t': time: unknown unit " seconds" in duration "5 seconds"`},
+				},
+				"negative duration": {
+					`{"t": "2021-11-15T09:00:00Z"}`,
+					`{"t": "<<TIME_WITHIN:-5s>>"}`,
+					[]string{`invalid directive '<<TIME_WITHIN:-5s>>' at '$.t': expected a duration no less than 0 but found -5s`},
+				},
+				"reversed range": {
+					`{"t": "2021-11-15T09:00:00Z"}`,
+					`{"t": "<<TIME_RANGE:2022-01-01T00:00:00Z..2021-01-01T00:00:00Z>>"}`,
+					[]string{`invalid directive '<<TIME_RANGE:2022-01-01T00:00:00Z..2021-01-01T00:00:00Z>>' at '$.t': expected 2022-01-01T00:00:00Z to be no later than 2021-01-01T00:00:00Z`},
+				},
+				"invalid range": {
+					`{"t": "2021-11-15T09:00:00Z"}`,
+					`{"t": "<<TIME_RANGE:2021-01-01T00:00:00Z>>"}`,
+					[]string{`invalid directive '<<TIME_RANGE:2021-01-01T00:00:00Z>>' at '$.t': expected two timestamps separated by '..'`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, clock) })
+			}
+		})
+
+		t.Run("with ABSENT and OPTIONAL directives", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"absent key": {
+					`{"name": "River"}`,
+					`{"name": "River", "password": "<<ABSENT>>"}`,
+					nil,
+				},
+				"present key": {
+					`{"name": "River", "password": "hunter2"}`,
+					`{"name": "River", "password": "<<ABSENT>>"}`,
+					[]string{`expected no value at '$.password', but found "hunter2"`},
+				},
+				"present key with null value": {
+					`{"password": null}`,
+					`{"password": "<<ABSENT>>"}`,
+					[]string{`expected no value at '$.password', but found null`},
+				},
+				"absent key with extra keys allowed": {
+					`{"id": 1, "password": "hunter2"}`,
+					`{"<<EXTRA_KEYS_ALLOWED>>": true, "password": "<<ABSENT>>"}`,
+					[]string{`expected no value at '$.password', but found "hunter2"`},
+				},
+				"optional missing key": {
+					`{"name": "River"}`,
+					`{"name": "River", "nickname": "<<OPTIONAL>>"}`,
+					nil,
+				},
+				"optional present key": {
+					`{"name": "River", "nickname": null}`,
+					`{"name": "River", "nickname": "<<OPTIONAL>>"}`,
+					nil,
+				},
+				"optional missing key with template": {
+					`{"name": "River"}`,
+					`{"name": "River", "address": {"<<OPTIONAL>>": {"city": "<<STRING>>"}}}`,
+					nil,
+				},
+				"optional present key matching template": {
+					`{"name": "River", "address": {"city": "Osiris"}}`,
+					`{"name": "River", "address": {"<<OPTIONAL>>": {"city": "<<STRING>>"}}}`,
+					nil,
+				},
+				"optional present key not matching template": {
+					`{"name": "River", "address": {"city": 1}}`,
+					`{"name": "River", "address": {"<<OPTIONAL>>": {"city": "<<STRING>>"}}}`,
+					[]string{`expected a string at '$.address.city' but found number`},
+				},
+				"optional key alongside missing keys": {
+					`{}`,
+					`{"name": "River", "nickname": "<<OPTIONAL>>"}`,
+					[]string{
+						`expected 1 keys at '$' but got 0 keys`,
+						`expected object key(s) ["name"] missing at '$'`,
+					},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("with NULLABLE directive", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"null":                      {`{"a": null}`, `{"a": "<<NULLABLE>>"}`, nil},
+				"any value":                 {`{"a": [1]}`, `{"a": "<<NULLABLE>>"}`, nil},
+				"null instead of directive": {`{"a": null}`, `{"a": "<<NULLABLE:<<STRING>>>>"}`, nil},
+				"value matching directive":  {`{"a": "b"}`, `{"a": "<<NULLABLE:<<STRING>>>>"}`, nil},
+				"value matching regex":      {`{"a": "2021-11-15"}`, `{"a": "<<NULLABLE:<<^\\d{4}-\\d{2}-\\d{2}$>>>>"}`, nil},
+				"null instead of literal":   {`{"a": null}`, `{"a": {"<<NULLABLE>>": {"b": 1}}}`, nil},
+				"value matching number":     {`{"a": 5.0}`, `{"a": "<<NULLABLE:5>>"}`, nil},
+				"value matching boolean":    {`{"a": true}`, `{"a": "<<NULLABLE:true>>"}`, nil},
+				"value matching string":     {`{"a": "b"}`, `{"a": "<<NULLABLE:\"b\">>"}`, nil},
+				"value not matching number": {
+					`{"a": 6}`,
+					`{"a": "<<NULLABLE:5>>"}`,
+					[]string{`expected number at '$.a' to be '5.0000000' but was '6.0000000'`},
+				},
+				"invalid literal": {
+					`{"a": "b"}`,
+					`{"a": "<<NULLABLE:b>>"}`,
+					[]string{`invalid directive '<<NULLABLE:b>>' at '$.a': expected a directive or a JSON literal, use {"<<NULLABLE>>": ...} to wrap other values`},
+				},
+				"value matching literal": {`{"a": {"b": 1}}`, `{"a": {"<<NULLABLE>>": {"b": 1}}}`, nil},
+				"null instead of object": {`{"a": null}`, `{"a": {"<<NULLABLE>>": "<<OBJECT>>"}}`, nil},
+				"null in arrays":         {`[null, 1]`, `[{"<<NULLABLE>>": 1}, {"<<NULLABLE>>": 1}]`, nil},
+				"value not matching directive": {
+					`{"a": 1}`,
+					`{"a": "<<NULLABLE:<<STRING>>>>"}`,
+					[]string{`expected a string at '$.a' but found number`},
+				},
+				"value not matching literal": {
+					`{"a": {"b": 2}}`,
+					`{"a": {"<<NULLABLE>>": {"b": 1}}}`,
+					[]string{`expected number at '$.a.b' to be '1.0000000' but was '2.0000000'`},
+				},
+				"missing key": {
+					`{}`,
+					`{"a": "<<NULLABLE>>"}`,
+					[]string{
+						`expected 1 keys at '$' but got 0 keys`,
+						`expected object key(s) ["a"] missing at '$'`,
+					},
+				},
+				"optional and nullable": {
+					`[{}, {"a": null}, {"a": "b"}]`,
+					`["<<UNORDERED>>", {"a": {"<<OPTIONAL>>": "<<NULLABLE:<<STRING>>>>"}}, {"a": {"<<OPTIONAL>>": "<<NULLABLE:<<STRING>>>>"}}, {"a": {"<<OPTIONAL>>": "<<NULLABLE:<<STRING>>>>"}}]`,
+					nil,
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("with EXTRA_KEYS_ALLOWED directive", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"subset of keys": {
+					`{"id": 1, "name": "River", "age": 16}`,
+					`{"<<EXTRA_KEYS_ALLOWED>>": true, "name": "River"}`,
+					nil,
+				},
+				"missing keys are still reported": {
+					`{"id": 1, "name": "River"}`,
+					`{"<<EXTRA_KEYS_ALLOWED>>": true, "age": 16}`,
+					[]string{`expected object key(s) ["age"] missing at '$'`},
+				},
+				"values are still compared": {
+					`{"id": 1, "name": "River"}`,
+					`{"<<EXTRA_KEYS_ALLOWED>>": true, "name": "Simon"}`,
+					[]string{`expected string at '$.name' to be 'Simon' but was 'River'`},
+				},
+				"only applies to its own level": {
+					`{"id": 1, "user": {"id": 2, "name": "River"}}`,
+					`{"<<EXTRA_KEYS_ALLOWED>>": true, "user": {"name": "River"}}`,
+					[]string{
+						`expected 1 keys at '$.user' but got 2 keys`,
+						`unexpected object key(s) ["id"] found at '$.user'`,
+					},
+				},
+				"recursive": {
+					`{"id": 1, "users": [{"id": 2, "name": "River"}]}`,
+					`{"<<EXTRA_KEYS_ALLOWED>>": "<<RECURSIVE>>", "users": [{"name": "River"}]}`,
+					nil,
+				},
+				"recursive can be disabled for nested objects": {
+					`{"id": 1, "user": {"id": 2, "name": "River", "address": {"city": "Osiris", "zip": "123"}}}`,
+					`{"<<EXTRA_KEYS_ALLOWED>>": "<<RECURSIVE>>", "user": {"<<EXTRA_KEYS_ALLOWED>>": false, "id": 2, "name": "River", "address": {"city": "Osiris"}}}`,
+					[]string{
+						`expected 1 keys at '$.user.address' but got 2 keys`,
+						`unexpected object key(s) ["zip"] found at '$.user.address'`,
+					},
+				},
+				"invalid marker value": {
+					`{"id": 1}`,
+					`{"<<EXTRA_KEYS_ALLOWED>>": "yes", "id": 1}`,
+					[]string{`expected the value of "<<EXTRA_KEYS_ALLOWED>>" at '$' to be true, false or "<<RECURSIVE>>" but was "yes"`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
 		t.Run("with REGULAR EXPRESSION directives", func(t *testing.T) {
 			for name, tc := range map[string]*testCase{
 				"presence against null": {
@@ -167,12 +727,7 @@ func TestAssertf(t *testing.T) {
 				"presence against number": {
 					`{"foo": 1234}`,
 					`{"foo": "<<^\\d{4}$>>"}`,
-					nil,
-				},
-				"presence against number fail": {
-					`{"foo": 1234}`,
-					`{"foo": "<<^\\d{3}$>>"}`,
-					[]string{`does not match by pattern: '<<^\d{3}$>>' with: '1234' path: '$.foo'`},
+					[]string{`cannot match regular expression '<<^\d{4}$>>' against number at '$.foo'; use jsonassert.WithRegexNumbers() to match numbers`},
 				},
 				"presence against string": {
 					`{"foo": "hello world"}`,
@@ -184,6 +739,21 @@ func TestAssertf(t *testing.T) {
 					`{"foo": "<<\\d+>>"}`,
 					[]string{`does not match by pattern: '<<\d+>>' with: 'hello world' path: '$.foo'`},
 				},
+				"presence against null value": {
+					`{"foo": null}`,
+					`{"foo": "<<^null$>>"}`,
+					nil,
+				},
+				"against object": {
+					`{"foo": {"bar": "baz"}}`,
+					`{"foo": "<<baz>>"}`,
+					[]string{`cannot match regular expression '<<baz>>' against object at '$.foo'; regular expressions only match strings, booleans and null`},
+				},
+				"against array": {
+					`{"foo": ["bar"]}`,
+					`{"foo": "<<=~bar>>"}`,
+					[]string{`cannot match regular expression '<<=~bar>>' against array at '$.foo'; regular expressions only match strings, booleans and null`},
+				},
 				"presence against object": {
 					`{"foo": {"bar": "baz"}}`,
 					`{"foo": {"bar": "<<baz>>"}}`,
@@ -211,6 +781,100 @@ func TestAssertf(t *testing.T) {
 				t.Run(name, func(t *testing.T) { tc.check(t) })
 			}
 		})
+
+		t.Run("with regular expressions against numbers", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"integer":         {`{"foo": 1234}`, `{"foo": "<<^\\d{4}$>>"}`, nil},
+				"trailing zeroes": {`{"foo": 1.50}`, `{"foo": "<<REGEX:^1\\.5$>>"}`, nil},
+				"exponent":        {`{"foo": 15e-1}`, `{"foo": "<<REGEX:^1\\.5$>>"}`, nil},
+				"large exponent":  {`{"foo": 1.234E+3}`, `{"foo": "<<REGEX:^1234$>>"}`, nil},
+				"small fraction":  {`{"foo": -0.00120}`, `{"foo": "<<REGEX:^-0\\.0012$>>"}`, nil},
+				"zero":            {`{"foo": -0.0e5}`, `{"foo": "<<REGEX:^0$>>"}`, nil},
+				"huge exponent":   {`{"foo": 12e1000000000}`, `{"foo": "<<REGEX:^1\\.2e1000000001$>>"}`, nil},
+				"not as written": {
+					`{"foo": 1.50}`,
+					`{"foo": "<<REGEX:^1\\.50$>>"}`,
+					[]string{`does not match by pattern: '<<REGEX:^1\.50$>>' with: '1.5' path: '$.foo'`},
+				},
+				"integer fail": {
+					`{"foo": 1234}`,
+					`{"foo": "<<^\\d{3}$>>"}`,
+					[]string{`does not match by pattern: '<<^\d{3}$>>' with: '1234' path: '$.foo'`},
+				},
+				"still no composites": {
+					`{"foo": [1]}`,
+					`{"foo": "<<REGEX:1>>"}`,
+					[]string{`cannot match regular expression '<<REGEX:1>>' against array at '$.foo'; regular expressions only match strings, booleans and null`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithRegexNumbers()) })
+			}
+		})
+
+		t.Run("with explicit REGEX directives", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"named directive":                  {`{"foo": "abc-123"}`, `{"foo": "<<REGEX:^[a-z]+-\\d+$>>"}`, nil},
+				"operator":                         {`{"foo": "abc-123"}`, `{"foo": "<<=~^[a-z]+-\\d+$>>"}`, nil},
+				"pattern looking like a directive": {`{"foo": "PRESENCE"}`, `{"foo": "<<REGEX:^[A-Z]+$>>"}`, nil},
+				"named directive fail": {
+					`{"foo": "abc"}`,
+					`{"foo": "<<REGEX:^\\d+$>>"}`,
+					[]string{`does not match by pattern: '<<REGEX:^\d+$>>' with: 'abc' path: '$.foo'`},
+				},
+				"operator fail": {
+					`{"foo": "abc"}`,
+					`{"foo": "<<=~^\\d+$>>"}`,
+					[]string{`does not match by pattern: '<<=~^\d+$>>' with: 'abc' path: '$.foo'`},
+				},
+				"empty pattern": {
+					`{"foo": "bar"}`,
+					`{"foo": "<<REGEX>>"}`,
+					[]string{`invalid directive '<<REGEX>>' at '$.foo': expected a pattern`},
+				},
+				"empty pattern with colon": {
+					`{"foo": true}`,
+					`{"foo": "<<REGEX:>>"}`,
+					[]string{`invalid directive '<<REGEX:>>' at '$.foo': expected a pattern`},
+				},
+				"empty operator pattern": {
+					`{"foo": null}`,
+					`{"foo": "<<=~>>"}`,
+					[]string{`invalid directive '<<=~>>' at '$.foo': expected a pattern`},
+				},
+				"unknown directive": {
+					`{"foo": "bar"}`,
+					`{"foo": "<<PRESENSE>>"}`,
+					[]string{`unknown directive '<<PRESENSE>>' at '$.foo'; use "<<REGEX:...>>" to match a regular expression`},
+				},
+				"unknown directive with arguments": {
+					`{"foo": "bar"}`,
+					`{"foo": "<<GREATER:0>>"}`,
+					[]string{`unknown directive '<<GREATER:0>>' at '$.foo'; use "<<REGEX:...>>" to match a regular expression`},
+				},
+				"array directive in place of a value": {
+					`{"foo": ["bar"]}`,
+					`{"foo": "<<UNORDERED>>"}`,
+					[]string{`directive '<<UNORDERED>>' at '$.foo' cannot be used in place of a value`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("without implicit regular expressions", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"literal string":   {`{"foo": "<<bar>>"}`, `{"foo": "<<bar>>"}`, nil},
+				"explicit regex":   {`{"foo": "bar"}`, `{"foo": "<<REGEX:^b>>"}`, nil},
+				"other directives": {`{"foo": "bar"}`, `{"foo": "<<STRING>>"}`, nil},
+				"no implicit regex": {
+					`{"foo": "bar"}`,
+					`{"foo": "<<^b>>"}`,
+					[]string{`expected string at '$.foo' to be '<<^b>>' but was 'bar'`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithoutImplicitRegex()) })
+			}
+		})
 	})
 
 	t.Run("arrays", func(t *testing.T) {
@@ -348,6 +1012,68 @@ but expected JSON was:
 						`actual JSON at '$' was: ["foo","boo","foo"], but expected JSON was: ["foo","boo"], potentially in a different order`,
 					},
 				},
+				"directives in elements": {
+					`[{"id": "abc", "name": "River"}, {"id": "def", "name": "Simon"}]`,
+					`["<<UNORDERED>>", {"id": "<<PRESENCE>>", "name": "Simon"}, {"id": "<<^a>>", "name": "River"}]`,
+					nil,
+				},
+				"nested unordered array elements": {
+					`[[1, 2], [3, 4]]`,
+					`["<<UNORDERED>>", ["<<UNORDERED>>", 4, 3], ["<<UNORDERED>>", 2, 1]]`,
+					nil,
+				},
+				"numbers written differently": {
+					`[1.0, 2e0]`,
+					`["<<UNORDERED>>", 2, 1]`,
+					nil,
+				},
+				"elements matching more than one expected element": {
+					`["a", "b"]`,
+					`["<<UNORDERED>>", "<<PRESENCE>>", "a"]`,
+					nil,
+				},
+				"closest matching objects": {
+					`[{"id": 1, "name": "River", "age": 16}, {"id": 2, "name": "Simon", "age": 19}]`,
+					`["<<UNORDERED>>", {"id": 2, "name": "Simon", "age": 19}, {"id": 1, "name": "River", "age": 17}]`,
+					[]string{
+						`actual JSON at '$[0]' did not match any expected element, differences to the closest expected element at '$[1]' follow`,
+						`expected number at '$[0].age' to be '17.0000000' but was '16.0000000'`,
+					},
+				},
+				"closest matching objects among several": {
+					`[{"id": 1, "name": "River", "age": 16}, {"id": 2, "name": "Simon", "age": 19}]`,
+					`["<<UNORDERED>>", {"id": 2, "name": "Simon", "age": 20}, {"id": 1, "name": "River", "age": 17}]`,
+					[]string{
+						`actual JSON at '$[0]' did not match any expected element, differences to the closest expected element at '$[1]' follow`,
+						`expected number at '$[0].age' to be '17.0000000' but was '16.0000000'`,
+						`actual JSON at '$[1]' did not match any expected element, differences to the closest expected element at '$[0]' follow`,
+						`expected number at '$[1].age' to be '20.0000000' but was '19.0000000'`,
+					},
+				},
+				"closest matching arrays": {
+					`[["a", "b"], ["c", "d"]]`,
+					`["<<UNORDERED>>", ["c", "d"], ["a", "c"]]`,
+					[]string{
+						`actual JSON at '$[0]' did not match any expected element, differences to the closest expected element at '$[1]' follow`,
+						`expected string at '$[0][1]' to be 'c' but was 'b'`,
+					},
+				},
+				"objects and scalars are not paired up": {
+					`[{"id": 1}, "a"]`,
+					`["<<UNORDERED>>", "a", "b"]`,
+					[]string{
+						`actual JSON at '$[0]' contained an unexpected element: {"id":1}`,
+						`expected JSON at '$[1]': "b" was missing from actual payload`,
+					},
+				},
+				"duplicates are matched one to one": {
+					`["a", "a"]`,
+					`["<<UNORDERED>>", "a", "b"]`,
+					[]string{
+						`actual JSON at '$[1]' contained an unexpected element: "a"`,
+						`expected JSON at '$[1]': "b" was missing from actual payload`,
+					},
+				},
 				"nested unordered arrays": {
 					// really long object means that serializing it the same is
 					// highly unlikely should the determinisim of JSON
@@ -360,6 +1086,57 @@ but expected JSON was:
 				t.Run(name, func(t *testing.T) { tc.check(t) })
 			}
 		})
+		t.Run("with CONTAINS directive", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"no expected elements":   {`["foo"]`, `["<<CONTAINS>>"]`, nil},
+				"all elements":           {`["foo", "bar"]`, `["<<CONTAINS>>", "bar", "foo"]`, nil},
+				"additional elements":    {`["foo", 1, {"a": true}, "bar"]`, `["<<CONTAINS>>", "bar", {"a": true}]`, nil},
+				"duplicate elements":     {`["foo", "bar", "foo"]`, `["<<CONTAINS>>", "foo", "foo"]`, nil},
+				"directives in elements": {`[{"id": 1, "n": "a"}, {"id": 2, "n": "b"}]`, `["<<CONTAINS>>", {"id": "<<PRESENCE>>", "n": "b"}]`, nil},
+				"elements matching more than one expected element": {`["a", "b", "c"]`, `["<<CONTAINS>>", "<<PRESENCE>>", "a"]`, nil},
+				"missing duplicate elements": {
+					`["foo", "bar"]`,
+					`["<<CONTAINS>>", "foo", "foo"]`,
+					[]string{`expected JSON at '$[1]': "foo" was missing from actual payload`},
+				},
+				"missing elements": {
+					`["foo", "bar"]`,
+					`["<<CONTAINS>>", "baz", "bar", "boo"]`,
+					[]string{
+						`expected JSON at '$[0]': "baz" was missing from actual payload`,
+						`expected JSON at '$[2]': "boo" was missing from actual payload`,
+					},
+				},
+				"empty actual array": {
+					`[]`,
+					`["<<CONTAINS>>", "foo"]`,
+					[]string{`expected JSON at '$[0]': "foo" was missing from actual payload`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
+
+		t.Run("with CONTAINS_IN_ORDER directive", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"no expected elements":   {`["foo"]`, `["<<CONTAINS_IN_ORDER>>"]`, nil},
+				"subsequence":            {`[1, 2, 3, 4, 5]`, `["<<CONTAINS_IN_ORDER>>", 2, 4, 5]`, nil},
+				"repeated elements":      {`[1, 2, 1, 2]`, `["<<CONTAINS_IN_ORDER>>", 2, 1, 2]`, nil},
+				"directives in elements": {`[{"id": 1}, {"id": 2}, {"id": 3}]`, `["<<CONTAINS_IN_ORDER>>", {"id": "<<PRESENCE>>"}, {"id": 3}]`, nil},
+				"out of order": {
+					`[1, 2, 3]`,
+					`["<<CONTAINS_IN_ORDER>>", 3, 1]`,
+					[]string{`expected JSON at '$[1]': 1 was missing from actual payload, or out of order`},
+				},
+				"missing elements": {
+					`[1, 2, 3]`,
+					`["<<CONTAINS_IN_ORDER>>", 1, 4, 3]`,
+					[]string{`expected JSON at '$[1]': 4 was missing from actual payload, or out of order`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t) })
+			}
+		})
 	})
 
 	t.Run("extra long strings should be formatted on a new line", func(t *testing.T) {
@@ -390,6 +1167,176 @@ potentially in a different order`,
 		}
 	})
 
+	t.Run("options", func(t *testing.T) {
+		t.Run("WithTolerance", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"within tolerance":                     {`1.04`, `1`, nil},
+				"within tolerance in unordered arrays": {`[2.01, 1.04]`, `["<<UNORDERED>>", 1, 2]`, nil},
+				"beyond float64 but equal":             {`[1e400]`, `[10e399]`, nil},
+				"outside tolerance": {
+					`1.2`,
+					`1`,
+					[]string{`expected number at '$' to be '1.0000000' but was '1.2000000'`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithTolerance(0.05)) })
+			}
+		})
+
+		t.Run("WithRelativeTolerance", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"large numbers within tolerance": {`1000000`, `1009000`, nil},
+				"small numbers within tolerance": {`0.000001`, `0.0000010099`, nil},
+				"zero":                           {`0`, `0`, nil},
+				"beyond float64 but equal":       {`[1e400]`, `[10e399]`, nil},
+				"outside tolerance": {
+					`0.000001`,
+					`0.0000011`,
+					[]string{`expected number at '$' to be '0.0000011' but was '0.0000010'`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithRelativeTolerance(0.01)) })
+			}
+		})
+
+		t.Run("WithULPTolerance", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"adjacent floats":            {`0.30000000000000004`, `0.3`, nil},
+				"tiny adjacent floats":       {`5e-324`, `1e-323`, nil},
+				"positive and negative zero": {`-0`, `0`, nil},
+				"beyond float64 but equal":   {`[1e400]`, `[10e399]`, nil},
+				"beyond float64": {
+					`[1e400]`,
+					`[1e500]`,
+					[]string{`expected number at '$[0]' to be '1e500' but was '1e400'`},
+				},
+				"underflowing float64": {
+					`[1e-400]`,
+					`[1e-500]`,
+					[]string{`expected number at '$[0]' to be '1e-500' but was '1e-400'`},
+				},
+				"outside tolerance": {
+					`0.30000000000000016`,
+					`0.3`,
+					[]string{`expected number at '$' to be '0.3' but was '0.30000000000000016'`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithULPTolerance(2)) })
+			}
+		})
+
+		t.Run("WithExactNumbers", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"equal numbers": {`1.5`, `1.50`, nil},
+				"tiny difference": {
+					`1.0000000001`,
+					`1`,
+					[]string{`expected number at '$' to be '1' but was '1.0000000001'`},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithExactNumbers()) })
+			}
+		})
+
+		t.Run("WithStrictNumbers", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"integers":  {`{"a": 1}`, `{"a": 1}`, nil},
+				"fractions": {`{"a": 1.50}`, `{"a": 1.5}`, nil},
+				"exponents": {`{"a": 1E2}`, `{"a": 1e+2}`, nil},
+				"fraction instead of integer": {
+					`{"a": 1.0}`,
+					`{"a": 1}`,
+					[]string{`expected number at '$.a' to be written as an integer ('1') but was written as a fraction ('1.0')`},
+				},
+				"integer instead of exponent": {
+					`{"a": 100}`,
+					`{"a": 1e2}`,
+					[]string{`expected number at '$.a' to be written as an exponent ('1e2') but was written as an integer ('100')`},
+				},
+				"different form and value": {
+					`{"a": 2.0}`,
+					`{"a": 1}`,
+					[]string{
+						`expected number at '$.a' to be written as an integer ('1') but was written as a fraction ('2.0')`,
+						`expected number at '$.a' to be '1' but was '2.0'`,
+					},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithStrictNumbers()) })
+			}
+		})
+
+		t.Run("WithExtraKeysAllowed", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"subsets in unordered arrays": {
+					`[{"id": 1, "name": "River"}, {"id": 2, "name": "Simon"}]`,
+					`["<<UNORDERED>>", {"name": "Simon"}, {"name": "River"}]`,
+					nil,
+				},
+				"nested subsets": {
+					`{"id": 1, "user": {"id": 2, "name": "River"}}`,
+					`{"user": {"name": "River"}}`,
+					nil,
+				},
+				"missing keys are still reported": {
+					`{"id": 1, "user": {"id": 2}}`,
+					`{"user": {"name": "River"}}`,
+					[]string{`expected object key(s) ["name"] missing at '$.user'`},
+				},
+				"strict again for a nested object": {
+					`{"id": 1, "user": {"id": 2, "name": "River"}}`,
+					`{"user": {"<<EXTRA_KEYS_ALLOWED>>": false, "name": "River"}}`,
+					[]string{
+						`expected 1 keys at '$.user' but got 2 keys`,
+						`unexpected object key(s) ["id"] found at '$.user'`,
+					},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithExtraKeysAllowed()) })
+			}
+		})
+
+		t.Run("WithLineLength", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"short strings on new line": {
+					`"hello"`,
+					`"world"`,
+					[]string{"expected string at '$' to be\n'world'\nbut was\n'hello'"},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithLineLength(5)) })
+			}
+		})
+
+		t.Run("WithoutDirectives", func(t *testing.T) {
+			for name, tc := range map[string]*testCase{
+				"literal presence":  {`"<<PRESENCE>>"`, `"<<PRESENCE>>"`, nil},
+				"literal regex":     {`{"foo": "<<^bar$>>"}`, `{"foo": "<<^bar$>>"}`, nil},
+				"literal unordered": {`["<<UNORDERED>>", "foo"]`, `["<<UNORDERED>>", "foo"]`, nil},
+				"presence is not a directive": {
+					`{"foo": "bar"}`,
+					`{"foo": "<<PRESENCE>>"}`,
+					[]string{`expected string at '$.foo' to be '<<PRESENCE>>' but was 'bar'`},
+				},
+				"regex is not a directive": {
+					`{"foo": "bar"}`,
+					`{"foo": "<<^bar$>>"}`,
+					[]string{`expected string at '$.foo' to be '<<^bar$>>' but was 'bar'`},
+				},
+				"unordered is not a directive": {
+					`["foo", "bar"]`,
+					`["<<UNORDERED>>", "foo"]`,
+					[]string{
+						`expected string at '$[0]' to be '<<UNORDERED>>' but was 'foo'`,
+						`expected string at '$[1]' to be 'foo' but was 'bar'`,
+					},
+				},
+			} {
+				t.Run(name, func(t *testing.T) { tc.check(t, jsonassert.WithoutDirectives()) })
+			}
+		})
+	})
+
 	t.Run("big fat test", func(t *testing.T) {
 		var (
 			bigFatPayloadActual, _   = ioutil.ReadFile("testdata/big-fat-payload-actual.json")
@@ -492,18 +1439,131 @@ was missing from actual payload`,
 	})
 }
 
+func TestRequiref(t *testing.T) {
+	t.Run("stops the test after reporting all differences", func(t *testing.T) {
+		tp := &failNowPrinter{}
+		if jsonassert.New(tp).Requiref(`{"a": 1, "b": 2}`, `{"a": 2, "b": 3}`) {
+			t.Errorf("expected Requiref to return false")
+		}
+		if got := len(tp.messages); got != 2 {
+			t.Errorf("expected 2 assertion messages but got %d", got)
+		}
+		if tp.failNowCalls != 1 {
+			t.Errorf("expected FailNow to be called once but was called %d time(s)", tp.failNowCalls)
+		}
+	})
+
+	t.Run("does not stop the test when payloads match", func(t *testing.T) {
+		tp := &failNowPrinter{}
+		if !jsonassert.New(tp).Requiref(`{"a": 1}`, `{"a": %d}`, 1) {
+			t.Errorf("expected Requiref to return true")
+		}
+		if len(tp.messages) != 0 || tp.failNowCalls != 0 {
+			t.Errorf("expected no messages and no FailNow calls but got %v and %d call(s)", tp.messages, tp.failNowCalls)
+		}
+	})
+
+	t.Run("stops the test on invalid JSON", func(t *testing.T) {
+		tp := &failNowPrinter{}
+		jsonassert.New(tp).Requiref(`{`, `{}`)
+		if tp.failNowCalls != 1 {
+			t.Errorf("expected FailNow to be called once but was called %d time(s)", tp.failNowCalls)
+		}
+	})
+
+	t.Run("falls back to Errorf for printers without FailNow", func(t *testing.T) {
+		tp := &testPrinter{}
+		jsonassert.New(tp).Requiref(`{"a": 1}`, `{"a": 2}`)
+		if got := len(tp.messages); got != 1 {
+			t.Errorf("expected 1 assertion message but got %d", got)
+		}
+	})
+}
+
+func TestAssertBytesReaderValue(t *testing.T) {
+	type person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	for name, tc := range map[string]struct {
+		assert func(ja *jsonassert.Asserter) bool
+		msgs   []string
+	}{
+		"bytes": {
+			func(ja *jsonassert.Asserter) bool { return ja.AssertBytes([]byte(`{"a": 1}`), `{"a": %d}`, 1) },
+			nil,
+		},
+		"bytes with differences": {
+			func(ja *jsonassert.Asserter) bool { return ja.AssertBytes([]byte(`{"a": 1}`), `{"a": 2}`) },
+			[]string{`expected number at '$.a' to be '2.0000000' but was '1.0000000'`},
+		},
+		"reader": {
+			func(ja *jsonassert.Asserter) bool { return ja.AssertReader(strings.NewReader(`["a"]`), `["a"]`) },
+			nil,
+		},
+		"failing reader": {
+			func(ja *jsonassert.Asserter) bool {
+				return ja.AssertReader(iotest.ErrReader(errors.New("oops")), `["a"]`)
+			},
+			[]string{`unable to read 'actual' JSON: oops`},
+		},
+		"struct": {
+			func(ja *jsonassert.Asserter) bool {
+				return ja.AssertValue(person{Name: "River Tam", Age: 16}, `{"name": "River Tam", "age": 16}`)
+			},
+			nil,
+		},
+		"struct with differences": {
+			func(ja *jsonassert.Asserter) bool {
+				return ja.AssertValue(&person{Name: "River Tam", Age: 16}, `{"name": "Simon Tam", "age": 16}`)
+			},
+			[]string{`expected string at '$.name' to be 'Simon Tam' but was 'River Tam'`},
+		},
+		"map": {
+			func(ja *jsonassert.Asserter) bool {
+				return ja.AssertValue(map[string]interface{}{"a": []int{1, 2}}, `{"a": [1, 2]}`)
+			},
+			nil,
+		},
+		"raw message": {
+			func(ja *jsonassert.Asserter) bool {
+				return ja.AssertValue(json.RawMessage(`{"a": true}`), `{"a": true}`)
+			},
+			nil,
+		},
+		"unmarshallable value": {
+			func(ja *jsonassert.Asserter) bool { return ja.AssertValue(make(chan int), `{}`) },
+			[]string{`unable to marshal 'actual' value of type chan int to JSON: json: unsupported type: chan int`},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			(&testCase{msgs: tc.msgs}).checkAssertion(t, tc.assert)
+		})
+	}
+}
+
 type testCase struct {
 	act, exp string
 	msgs     []string
 }
 
-func (tc *testCase) check(t *testing.T) {
+func (tc *testCase) check(t *testing.T, opts ...jsonassert.Option) {
+	tc.checkAssertion(t, func(ja *jsonassert.Asserter) bool { return ja.Assertf(tc.act, tc.exp) }, opts...)
+}
+
+// checkAssertion works like check, but makes the assertion with the given
+// function rather than with Assertf.
+func (tc *testCase) checkAssertion(t *testing.T, assert func(ja *jsonassert.Asserter) bool, opts ...jsonassert.Option) {
 	tp := &testPrinter{}
-	jsonassert.New(tp).Assertf(tc.act, tc.exp)
+	ok := assert(jsonassert.New(tp, opts...))
 
 	if got := len(tp.messages); got != len(tc.msgs) {
 		t.Errorf("expected %d assertion message(s) but got %d", len(tc.msgs), got)
 	}
+	if ok != (len(tc.msgs) == 0) {
+		t.Errorf("expected the assertion to return %v but got %v", len(tc.msgs) == 0, ok)
+	}
 
 	for _, expMsg := range tc.msgs {
 		found := false
@@ -537,3 +1597,12 @@ func (tp *testPrinter) Errorf(msg string, args ...interface{}) {
 func (tp *testPrinter) Helper() {
 	// Do nothing in tests
 }
+
+type failNowPrinter struct {
+	testPrinter
+	failNowCalls int
+}
+
+func (fp *failNowPrinter) FailNow() {
+	fp.failNowCalls++
+}
@@ -1,20 +1,303 @@
 package jsonassert
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
-// This is *probably* good enough. Can change this to be even smaller if necessary
-const minDiff = 0.000001
+type toleranceKind int
 
-func (a *Asserter) checkNumber(path string, act, exp float64) {
-	a.tt.Helper()
-	if diff := math.Abs(act - exp); diff > minDiff {
-		a.tt.Errorf("expected number at '%s' to be '%.7f' but was '%.7f'", path, exp, act)
+const (
+	noTolerance toleranceKind = iota
+	absoluteTolerance
+	relativeTolerance
+	ulpTolerance
+)
+
+// tolerance decides whether two numbers are close enough to be considered
+// equal. See the With*Tolerance options.
+type tolerance struct {
+	kind  toleranceKind
+	value float64
+	ulps  uint64
+}
+
+func (t tolerance) equal(act, exp json.Number) bool {
+	// Compare at full precision, so that neither large integers like IDs
+	// nor long decimals like amounts of money are rounded.
+	if cmpNumbers(act, exp) == 0 {
+		return true
+	}
+	if t.kind == noTolerance {
+		return false
+	}
+	actFloat, expFloat := toFloat(act), toFloat(exp)
+	if !representable(act, actFloat) || !representable(exp, expFloat) {
+		// No tolerance makes sense for numbers beyond the range of a
+		// float64, and the exact comparison already failed.
+		return false
+	}
+	switch t.kind {
+	case relativeTolerance:
+		return math.Abs(actFloat-expFloat) <= t.value*math.Max(math.Abs(actFloat), math.Abs(expFloat))
+	case ulpTolerance:
+		return ulpDistance(actFloat, expFloat) <= t.ulps
+	}
+	return math.Abs(actFloat-expFloat) <= t.value
+}
+
+// ulpDistance counts the number of representable float64 values between a
+// and b.
+func ulpDistance(a, b float64) uint64 {
+	ia, ib := orderedBits(a), orderedBits(b)
+	if ia < ib {
+		ia, ib = ib, ia
 	}
+	return uint64(ia) - uint64(ib)
 }
 
-func extractNumber(n string) (float64, error) {
-	return strconv.ParseFloat(n, 64)
+// orderedBits maps floats onto integers such that adjacent floats map onto
+// adjacent integers, with -0 and +0 both mapping onto 0.
+func orderedBits(f float64) int64 {
+	i := int64(math.Float64bits(f))
+	if i < 0 {
+		i = math.MinInt64 - i
+	}
+	return i
+}
+
+func (c *comparer) checkNumber(path string, act, exp json.Number) {
+	if c.cfg.strictNumbers {
+		if actForm, expForm := formOf(act), formOf(exp); actForm != expForm {
+			c.report(TypeMismatch, path, act, exp,
+				fmt.Sprintf("expected number at '%s' to be written as %s ('%s') but was written as %s ('%s')", path, expForm, exp, actForm, act))
+		}
+	}
+	if !c.cfg.tolerance.equal(act, exp) {
+		actString, expString := act.String(), exp.String()
+		if !c.cfg.strictNumbers {
+			actString, expString = formatNumbers(act, exp)
+		}
+		c.report(ValueMismatch, path, act, exp,
+			fmt.Sprintf("expected number at '%s' to be '%s' but was '%s'", path, expString, actString))
+	}
+}
+
+type numberForm string
+
+const (
+	integerForm  numberForm = "an integer"
+	fractionForm numberForm = "a fraction"
+	exponentForm numberForm = "an exponent"
+)
+
+// formOf finds the form in which the number was written in the payload.
+func formOf(n json.Number) numberForm {
+	if strings.ContainsAny(n.String(), "eE") {
+		return exponentForm
+	}
+	if strings.Contains(n.String(), ".") {
+		return fractionForm
+	}
+	return integerForm
+}
+
+// formatNumbers formats the numbers with 7 decimal places, unless that would
+// make two different numbers look identical, or either number is beyond the
+// range of a float64, in which case they are formatted as they appear in the
+// payloads.
+func formatNumbers(act, exp json.Number) (string, string) {
+	actFloat, expFloat := toFloat(act), toFloat(exp)
+	actString, expString := fmt.Sprintf("%.7f", actFloat), fmt.Sprintf("%.7f", expFloat)
+	if actString == expString || math.IsInf(actFloat, 0) || math.IsInf(expFloat, 0) {
+		return act.String(), exp.String()
+	}
+	return actString, expString
+}
+
+// representable reports whether the number converted into the given float64
+// is neither +/-Inf nor 0 only because it overflowed or underflowed.
+func representable(n json.Number, f float64) bool {
+	if math.IsInf(f, 0) {
+		return false
+	}
+	return f != 0 || canonicalNumber(n) == "0"
+}
+
+// toRat converts the number into a big.Rat. The bool is false for numbers
+// with exponents too large to be represented, e.g. 1e1000000000.
+func toRat(n json.Number) (*big.Rat, bool) {
+	return new(big.Rat).SetString(n.String())
+}
+
+// cmpNumbers compares two numbers at full precision, like big.Rat.Cmp.
+func cmpNumbers(a, b json.Number) int {
+	aRat, aOK := toRat(a)
+	bRat, bOK := toRat(b)
+	if aOK && bOK {
+		return aRat.Cmp(bRat)
+	}
+	// Numbers too large for a big.Rat are also too large for a float64, so
+	// they become +/-Inf, or 0 for tiny numbers, which still compare sensibly
+	// against other numbers.
+	aFloat, bFloat := toFloat(a), toFloat(b)
+	switch {
+	case aFloat < bFloat:
+		return -1
+	case aFloat > bFloat:
+		return 1
+	}
+	return 0
+}
+
+// isInteger reports whether the number has no fractional part.
+func isInteger(n json.Number) bool {
+	if r, ok := toRat(n); ok {
+		return r.IsInt()
+	}
+	// Only numbers with huge exponents can't be converted; positive ones are
+	// integers, and negative ones are tiny fractions.
+	return math.IsInf(toFloat(n), 0)
+}
+
+func toFloat(n json.Number) float64 {
+	// Numbers too large for a float64 become +/-Inf, which is as close as we
+	// can get.
+	f, _ := n.Float64()
+	return f
+}
+
+func extractNumber(n string) (json.Number, error) {
+	n = strings.TrimSpace(n)
+	var v interface{}
+	if err := unmarshal(n, &v); err != nil {
+		return "", err
+	}
+	num, ok := v.(json.Number)
+	if !ok {
+		return "", fmt.Errorf("cannot parse '%s' as number", n)
+	}
+	return num, nil
+}
+
+// boundDirective creates a directive that verifies that the actual value is a
+// number that compares against the number given as its argument as accepted
+// by the given function, e.g. "<<GT:0>>".
+func boundDirective(name, description string, accept func(cmp int) bool) valueDirective {
+	return func(c *comparer, path string, act interface{}, args string) {
+		bound, err := parseNumber(args)
+		if err != nil {
+			c.reportInvalidDirective(path, act, name, args, err)
+			return
+		}
+		if n, ok := c.actualNumber(path, act, fmt.Sprintf("a number %s %s", description, bound)); ok && !accept(cmpNumbers(n, bound)) {
+			c.report(DirectiveFailure, path, act, fmt.Sprintf("<<%s:%s>>", name, args),
+				fmt.Sprintf("expected a number %s %s at '%s' but found '%s'", description, bound, path, n))
+		}
+	}
+}
+
+// checkRange accepts numbers between the two numbers given as its argument,
+// inclusive, e.g. "<<RANGE:0..1>>".
+func checkRange(c *comparer, path string, act interface{}, args string) {
+	from, to, err := parseNumberRange(args)
+	if err != nil {
+		c.reportInvalidDirective(path, act, "RANGE", args, err)
+		return
+	}
+	description := fmt.Sprintf("a number between %s and %s", from, to)
+	n, ok := c.actualNumber(path, act, description)
+	if ok && (cmpNumbers(n, from) < 0 || cmpNumbers(n, to) > 0) {
+		c.report(DirectiveFailure, path, act, "<<RANGE:"+args+">>",
+			fmt.Sprintf("expected %s at '%s' but found '%s'", description, path, n))
+	}
+}
+
+func parseNumberRange(args string) (from, to json.Number, err error) {
+	parts := strings.Split(args, "..")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected two numbers separated by '..'")
+	}
+	if from, err = parseNumber(parts[0]); err != nil {
+		return "", "", err
+	}
+	if to, err = parseNumber(parts[1]); err != nil {
+		return "", "", err
+	}
+	if cmpNumbers(from, to) > 0 {
+		return "", "", fmt.Errorf("expected %s to be no greater than %s", from, to)
+	}
+	return from, to, nil
+}
+
+// maxCanonicalDigits is the largest number of digits that canonicalNumber
+// writes out before and after the decimal point, beyond which it resorts to an
+// exponent rather than printing endless zeros.
+const maxCanonicalDigits = 1000
+
+// canonicalNumber writes the number as the shortest exact decimal, so that
+// equal numbers are written the same way regardless of how they appear in the
+// payload, e.g. 1.50 and 15e-1 are both written as 1.5, and 1e3 as 1000.
+// Numbers whose decimal would exceed maxCanonicalDigits are written as a
+// single digit, the remaining digits as a fraction and an exponent instead,
+// e.g. 1.5e2000.
+func canonicalNumber(n json.Number) string {
+	s, sign := n.String(), ""
+	if strings.HasPrefix(s, "-") {
+		s, sign = s[1:], "-"
+	}
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i != -1 {
+		var err error
+		if exp, err = strconv.Atoi(s[i+1:]); err != nil {
+			// The exponent doesn't even fit in an int.
+			return n.String()
+		}
+		mantissa = s[:i]
+	}
+	// point is the position of the decimal point within digits.
+	point := len(mantissa)
+	if i := strings.IndexByte(mantissa, '.'); i != -1 {
+		point, mantissa = i, mantissa[:i]+mantissa[i+1:]
+	}
+	digits := strings.TrimLeft(mantissa, "0")
+	point += exp - (len(mantissa) - len(digits))
+	digits = strings.TrimRight(digits, "0")
+	switch {
+	case digits == "":
+		return "0"
+	case point > maxCanonicalDigits || point < -maxCanonicalDigits:
+		if len(digits) == 1 {
+			return fmt.Sprintf("%s%se%d", sign, digits, point-1)
+		}
+		return fmt.Sprintf("%s%s.%se%d", sign, digits[:1], digits[1:], point-1)
+	case point <= 0:
+		return sign + "0." + strings.Repeat("0", -point) + digits
+	case point >= len(digits):
+		return sign + digits + strings.Repeat("0", point-len(digits))
+	}
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// parseNumber parses a directive argument as a JSON number.
+func parseNumber(s string) (json.Number, error) {
+	n, err := extractNumber(s)
+	if err != nil {
+		return "", fmt.Errorf("'%s' is not a number", s)
+	}
+	return n, nil
+}
+
+// actualNumber reports a difference if the actual value isn't a number.
+func (c *comparer) actualNumber(path string, act interface{}, description string) (json.Number, bool) {
+	n, ok := act.(json.Number)
+	if !ok {
+		c.report(TypeMismatch, path, act, nil,
+			fmt.Sprintf("expected %s at '%s' but found %s", description, path, typeOf(act)))
+	}
+	return n, ok
 }
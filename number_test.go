@@ -0,0 +1,28 @@
+package jsonassert
+
+import (
+	"math"
+	"testing"
+)
+
+func TestULPDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b float64
+		want uint64
+	}{
+		{name: "equal", a: 1, b: 1, want: 0},
+		{name: "adjacent", a: 1, b: math.Nextafter(1, 2), want: 1},
+		{name: "adjacent reversed", a: math.Nextafter(1, 2), b: 1, want: 1},
+		{name: "zeroes", a: math.Copysign(0, -1), b: 0, want: 0},
+		{name: "across zero", a: -math.SmallestNonzeroFloat64, b: math.SmallestNonzeroFloat64, want: 2},
+		{name: "negative adjacent", a: -1, b: math.Nextafter(-1, -2), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ulpDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("ulpDistance(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,32 +1,69 @@
 package jsonassert
 
 import (
-	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 )
 
-func (a *Asserter) checkObject(path string, act, exp map[string]interface{}) {
-	a.tt.Helper()
-	if len(act) != len(exp) {
-		a.tt.Errorf("expected %d keys at '%s' but got %d keys", len(exp), path, len(act))
+// extraKeysAllowedKey may be given as a key in an expected object in order to
+// ignore any keys of the actual object that are not in the expected object.
+const extraKeysAllowedKey = "<<EXTRA_KEYS_ALLOWED>>"
+
+func (c *comparer) checkObject(path string, act, exp map[string]interface{}) {
+	// Whether extra keys are allowed at this level may be overridden for
+	// this level only, or for all nested levels too.
+	inherited := c.extraKeysAllowed
+	defer func() { c.extraKeysAllowed = inherited }()
+	extraKeysAllowed := inherited
+	if marker, ok := exp[extraKeysAllowedKey]; ok && c.cfg.directives {
+		delete(exp, extraKeysAllowedKey)
+		switch marker {
+		case true:
+			extraKeysAllowed = true
+		case false:
+			extraKeysAllowed, c.extraKeysAllowed = false, false
+		case "<<RECURSIVE>>":
+			extraKeysAllowed, c.extraKeysAllowed = true, true
+		default:
+			c.report(DirectiveFailure, path, nil, marker,
+				fmt.Sprintf(`expected the value of "%s" at '%s' to be true, false or "<<RECURSIVE>>" but was %s`, extraKeysAllowedKey, path, serialize(marker)))
+		}
 	}
-	if unique := difference(act, exp); len(unique) != 0 {
-		a.tt.Errorf("unexpected object key(s) %+v found at '%s'", serialize(unique), path)
+
+	// Keys that may be missing are left out of the key comparison if they are.
+	if c.cfg.directives {
+		for key, expVal := range exp {
+			if _, present := act[key]; !present && mayBeMissing(expVal) {
+				delete(exp, key)
+			}
+		}
+	}
+
+	if !extraKeysAllowed {
+		if len(act) != len(exp) {
+			c.report(LengthMismatch, path, len(act), len(exp),
+				fmt.Sprintf("expected %d keys at '%s' but got %d keys", len(exp), path, len(act)))
+		}
+		if unique := difference(act, exp); len(unique) != 0 {
+			c.report(ExtraKey, path, unique, nil,
+				fmt.Sprintf("unexpected object key(s) %+v found at '%s'", serialize(unique), path))
+		}
 	}
 	if unique := difference(exp, act); len(unique) != 0 {
-		a.tt.Errorf("expected object key(s) %+v missing at '%s'", serialize(unique), path)
+		c.report(MissingKey, path, nil, unique,
+			fmt.Sprintf("expected object key(s) %+v missing at '%s'", serialize(unique), path))
 	}
-	for key := range act {
+	for _, key := range sortedKeys(act) {
 		if contains(exp, key) {
-			a.pathassertf(path+"."+key, serialize(act[key]), serialize(exp[key]))
+			c.pathassertf(path+"."+key, serialize(act[key]), serialize(exp[key]))
 		}
 	}
 }
 
 func difference(act, exp map[string]interface{}) []string {
 	unique := []string{}
-	for key := range act {
+	for _, key := range sortedKeys(act) {
 		if !contains(exp, key) {
 			unique = append(unique, key)
 		}
@@ -34,6 +71,17 @@ func difference(act, exp map[string]interface{}) []string {
 	return unique
 }
 
+// sortedKeys returns the keys of the object in sorted order, so that
+// differences are always reported in the same order.
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func contains(container map[string]interface{}, candidate string) bool {
 	for key := range container {
 		if key == candidate {
@@ -52,6 +100,6 @@ func extractObject(s string) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("cannot parse '%s' as object", s)
 	}
 	var arr map[string]interface{}
-	err := json.Unmarshal([]byte(s), &arr)
+	err := unmarshal(s, &arr)
 	return arr, err
 }
@@ -0,0 +1,163 @@
+package jsonassert
+
+import "time"
+
+// Option configures the behavior of an *Asserter. Options are given to New
+// after the Printer, e.g.
+//
+//	ja := jsonassert.New(t, jsonassert.WithTolerance(0.01))
+type Option func(*config)
+
+// config holds everything that may be tweaked by an Option.
+type config struct {
+	// tolerance decides how far apart two numbers may be while still being
+	// considered equal.
+	tolerance tolerance
+	// strictNumbers is true when numbers must also be written in the same
+	// form, e.g. as integers, to be considered equal.
+	strictNumbers bool
+	// extraKeysAllowed is true when actual objects may contain keys that are
+	// not in the expected objects.
+	extraKeysAllowed bool
+	// clock gives the current time for directives like "<<TIME_WITHIN:5s>>".
+	clock func() time.Time
+	// lineLength is the length at which the values in a failure message are
+	// printed on lines of their own rather than inline.
+	lineLength int
+	// customDirectives holds the directives registered on an Asserter, see
+	// Asserter.RegisterDirective.
+	customDirectives map[string]DirectiveFunc
+	// implicitRegex is false when only "<<REGEX:...>>" and "<<=~...>>" should
+	// be treated as regular expressions, rather than any "<<...>>".
+	implicitRegex bool
+	// regexNumbers is true when regular expressions may be matched against
+	// numbers.
+	regexNumbers bool
+	// directives is false when directives like "<<PRESENCE>>" should be
+	// treated as literal strings.
+	directives bool
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		clock:         time.Now,
+		lineLength:    50,
+		implicitRegex: true,
+		directives:    true,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithTolerance sets the largest absolute difference between an actual and an
+// expected number for the two to be considered equal. By default numbers are
+// compared exactly, at full precision.
+func WithTolerance(tolerance float64) Option {
+	return func(cfg *config) {
+		cfg.tolerance.kind, cfg.tolerance.value = absoluteTolerance, tolerance
+	}
+}
+
+// WithRelativeTolerance sets the largest difference between an actual and an
+// expected number, relative to the larger of the two, for the two to be
+// considered equal. E.g. a tolerance of 0.01 considers 99 and 100 equal, as
+// well as 0.99 and 1. This is useful for numbers of widely varying magnitudes.
+func WithRelativeTolerance(tolerance float64) Option {
+	return func(cfg *config) {
+		cfg.tolerance.kind, cfg.tolerance.value = relativeTolerance, tolerance
+	}
+}
+
+// WithULPTolerance sets the largest number of representable float64 values
+// (units in the last place) that may lie between an actual and an expected
+// number for the two to be considered equal. This is useful for very small or
+// very large numbers where an absolute tolerance makes little sense.
+func WithULPTolerance(ulps uint64) Option {
+	return func(cfg *config) {
+		cfg.tolerance.kind, cfg.tolerance.ulps = ulpTolerance, ulps
+	}
+}
+
+// WithExactNumbers disables any numeric tolerance given in an earlier option,
+// so that numbers are only considered equal if they are exactly equal at full
+// precision. This is the default.
+func WithExactNumbers() Option {
+	return func(cfg *config) {
+		cfg.tolerance = tolerance{kind: noTolerance}
+	}
+}
+
+// WithStrictNumbers makes number comparisons also verify that the actual
+// number is written in the same form as the expected number: as an integer
+// (1), as a fraction (1.0), or with an exponent (1e0). This is useful when
+// consumers of the payload are strongly typed, and e.g. break when an integer
+// field contains 1.0. Numbers in failure messages are printed as they appear in
+// the payloads in this mode.
+func WithStrictNumbers() Option {
+	return func(cfg *config) {
+		cfg.strictNumbers = true
+	}
+}
+
+// WithExtraKeysAllowed makes all object comparisons check that the expected
+// object is a subset of the actual object, i.e. keys in the actual object that
+// are not in the expected object are ignored. Keys in the expected object that
+// are missing from the actual object are still reported.
+// Use {"<<EXTRA_KEYS_ALLOWED>>": false} in an expected object to make the
+// comparison of that object, and the objects nested within it, strict again.
+func WithExtraKeysAllowed() Option {
+	return func(cfg *config) {
+		cfg.extraKeysAllowed = true
+	}
+}
+
+// WithClock sets the function that gives the current time for directives
+// like "<<TIME_WITHIN:5s>>", in order to keep tests deterministic.
+// Defaults to time.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(cfg *config) {
+		cfg.clock = clock
+	}
+}
+
+// WithLineLength sets the length at which actual and expected values in
+// failure messages are printed on lines of their own instead of inline.
+// Defaults to 50.
+func WithLineLength(length int) Option {
+	return func(cfg *config) {
+		cfg.lineLength = length
+	}
+}
+
+// WithoutDirectives disables all directives, such as "<<PRESENCE>>",
+// "<<UNORDERED>>" and regular expressions, so that they are compared like any
+// other string. This is useful when the payload under test legitimately
+// contains such strings.
+func WithoutDirectives() Option {
+	return func(cfg *config) {
+		cfg.directives = false
+	}
+}
+
+// WithoutImplicitRegex makes only "<<REGEX:pattern>>" and "<<=~pattern>>" match
+// regular expressions. By default any expected string of the form
+// "<<pattern>>" that isn't a named directive is treated as a regular
+// expression, so that e.g. "<<x>>" can't be compared literally.
+func WithoutImplicitRegex() Option {
+	return func(cfg *config) {
+		cfg.implicitRegex = false
+	}
+}
+
+// WithRegexNumbers makes regular expressions match numbers written as their
+// shortest exact decimal, e.g. "<<REGEX:^\\d{4}$>>" matches 1234 as well as
+// 1.234e3, and "<<REGEX:^1\\.5$>>" matches 1.50. By default only strings,
+// booleans and null are matched, and a regular expression in place of a number
+// is reported as a type mismatch.
+func WithRegexNumbers() Option {
+	return func(cfg *config) {
+		cfg.regexNumbers = true
+	}
+}
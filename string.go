@@ -7,39 +7,73 @@ import (
 	"strings"
 )
 
-func (a *Asserter) checkString(path, act, exp string) {
-	a.tt.Helper()
-
-	isExpRegEx, err := isRegEx(exp)
+// checkRegex verifies that the actual string matches the pattern of the
+// expected regular expression directive.
+func (c *comparer) checkRegex(path, act, exp, pattern string) {
+	matched, err := regexp.MatchString(pattern, act)
 	if err != nil {
-		a.tt.Errorf("expected string check for regex error '%s', path: '%s' , exp reg ex: '%s'", err, path, exp)
+		c.report(DirectiveFailure, path, act, exp,
+			fmt.Sprintf("error on matching: '%v' with: '%v' in path: '%v'", exp, act, path))
 		return
 	}
-	if isExpRegEx {
 
-		regex := getReqExPattern(exp)
-		if regex == "" {
-			a.tt.Errorf("can't get reg ex string from '%s', path: '%s'", exp, path)
-			return
-		}
+	if !matched {
+		c.report(DirectiveFailure, path, act, exp,
+			fmt.Sprintf("does not match by pattern: '%v' with: '%v' path: '%v'", exp, act, path))
+	}
+}
 
-		matched, err := regexp.MatchString(regex, act)
-		if err != nil {
-			a.tt.Errorf("error on matching: '%v' with: '%v' in path: '%v'", exp, act, path)
-			return
+// regexSubject returns the canonical representation of the actual value that a
+// regular expression is matched against: the contents of strings, the literal
+// true, false and null, and, with WithRegexNumbers, numbers in their canonical
+// form, see canonicalNumber. Any other actual value is reported as a type
+// mismatch.
+func (c *comparer) regexSubject(path, act string, actType jsonType, exp string) (string, bool) {
+	switch actType {
+	case jsonString:
+		s, _ := extractString(act)
+		return s, true
+	case jsonBoolean, jsonNull:
+		return strings.TrimSpace(act), true
+	case jsonNumber:
+		if c.cfg.regexNumbers {
+			n, _ := extractNumber(act)
+			return canonicalNumber(n), true
 		}
+		c.report(TypeMismatch, path, decode(act), exp,
+			fmt.Sprintf("cannot match regular expression '%s' against number at '%s'; use jsonassert.WithRegexNumbers() to match numbers", exp, path))
+	default:
+		c.report(TypeMismatch, path, decode(act), exp,
+			fmt.Sprintf("cannot match regular expression '%s' against %s at '%s'; regular expressions only match strings, booleans and null", exp, actType, path))
+	}
+	return "", false
+}
 
-		if !matched {
-			a.tt.Errorf("does not match by pattern: '%v' with: '%v' path: '%v'", exp, act, path)
-		}
+// regexPattern extracts the pattern from a regular expression directive:
+// either "<<REGEX:pattern>>" or "<<=~pattern>>", or, unless implicit regular
+// expressions have been disabled, any other "<<pattern>>" that isn't a named
+// directive.
+func (c *comparer) regexPattern(exp string) (string, bool) {
+	if name, args, ok := parseDirective(exp); ok {
+		return args, name == "REGEX"
+	}
+	if len(exp) >= len("<<=~>>") && strings.HasPrefix(exp, "<<=~") && strings.HasSuffix(exp, ">>") {
+		return exp[len("<<=~") : len(exp)-len(">>")], true
+	}
+	if yes, err := isRegEx(exp); c.cfg.implicitRegex && err == nil && yes {
+		return getReqExPattern(exp), true
+	}
+	return "", false
+}
 
-	} else {
-		if act != exp {
-			if len(exp+act) < 50 {
-				a.tt.Errorf("expected string at '%s' to be '%s' but was '%s'", path, exp, act)
-			} else {
-				a.tt.Errorf("expected string at '%s' to be\n'%s'\nbut was\n'%s'", path, exp, act)
-			}
+func (c *comparer) checkStringEquality(path, act, exp string) {
+	if act != exp {
+		if len(exp+act) < c.cfg.lineLength {
+			c.report(ValueMismatch, path, act, exp,
+				fmt.Sprintf("expected string at '%s' to be '%s' but was '%s'", path, exp, act))
+		} else {
+			c.report(ValueMismatch, path, act, exp,
+				fmt.Sprintf("expected string at '%s' to be\n'%s'\nbut was\n'%s'", path, exp, act))
 		}
 	}
 }
@@ -0,0 +1,95 @@
+package jsonassert
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// checkTime accepts timestamps that represent the same instant as the
+// timestamp given as its argument, regardless of time zone or precision, e.g.
+// "<<TIME:2021-11-15T09:00:00Z>>" accepts "2021-11-15T18:00:00.000+09:00".
+func checkTime(c *comparer, path string, act interface{}, args string) {
+	exp, err := time.Parse(time.RFC3339Nano, args)
+	if err != nil {
+		c.reportInvalidDirective(path, act, "TIME", args, err)
+		return
+	}
+	if got, ok := c.actualTime(path, act); ok && !got.Equal(exp) {
+		c.report(DirectiveFailure, path, act, args,
+			fmt.Sprintf("expected a timestamp at '%s' representing the same instant as '%s' but found '%s'", path, args, act))
+	}
+}
+
+// checkTimeWithin accepts timestamps that are within the duration given as its
+// argument of the current time, e.g. "<<TIME_WITHIN:5s>>". The current time is
+// given by the clock, see WithClock.
+func checkTimeWithin(c *comparer, path string, act interface{}, args string) {
+	within, err := time.ParseDuration(args)
+	if err == nil && within < 0 {
+		err = fmt.Errorf("expected a duration no less than 0 but found %s", within)
+	}
+	if err != nil {
+		c.reportInvalidDirective(path, act, "TIME_WITHIN", args, err)
+		return
+	}
+	got, ok := c.actualTime(path, act)
+	if !ok {
+		return
+	}
+	now := c.cfg.clock()
+	if diff := got.Sub(now); diff < -within || diff > within {
+		c.report(DirectiveFailure, path, act, args,
+			fmt.Sprintf("expected a timestamp within %s of now (%s) at '%s' but found '%s', which is %s off", within, now.Format(time.RFC3339Nano), path, act, diff))
+	}
+}
+
+// checkTimeRange accepts timestamps between the two timestamps given as its
+// argument, inclusive, e.g.
+// "<<TIME_RANGE:2021-01-01T00:00:00Z..2022-01-01T00:00:00Z>>".
+func checkTimeRange(c *comparer, path string, act interface{}, args string) {
+	from, to, err := parseTimeRange(args)
+	if err != nil {
+		c.reportInvalidDirective(path, act, "TIME_RANGE", args, err)
+		return
+	}
+	if got, ok := c.actualTime(path, act); ok && (got.Before(from) || got.After(to)) {
+		c.report(DirectiveFailure, path, act, args,
+			fmt.Sprintf("expected a timestamp between '%s' and '%s' at '%s' but found '%s'", from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano), path, act))
+	}
+}
+
+func parseTimeRange(args string) (from, to time.Time, err error) {
+	parts := strings.Split(args, "..")
+	if len(parts) != 2 {
+		return from, to, fmt.Errorf("expected two timestamps separated by '..'")
+	}
+	if from, err = time.Parse(time.RFC3339Nano, parts[0]); err != nil {
+		return from, to, err
+	}
+	if to, err = time.Parse(time.RFC3339Nano, parts[1]); err != nil {
+		return from, to, err
+	}
+	if from.After(to) {
+		return from, to, fmt.Errorf("expected %s to be no later than %s", parts[0], parts[1])
+	}
+	return from, to, nil
+}
+
+// actualTime parses the actual value as an RFC 3339 timestamp, and reports a
+// difference if it isn't one.
+func (c *comparer) actualTime(path string, act interface{}) (time.Time, bool) {
+	s, ok := act.(string)
+	if !ok {
+		c.report(TypeMismatch, path, act, nil,
+			fmt.Sprintf("expected an RFC 3339 timestamp at '%s' but found %s", path, typeOf(act)))
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		c.report(DirectiveFailure, path, act, nil,
+			fmt.Sprintf("expected an RFC 3339 timestamp at '%s' but found '%s': %s", path, s, err.Error()))
+		return time.Time{}, false
+	}
+	return t, true
+}